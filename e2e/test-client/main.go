@@ -28,7 +28,8 @@ func main() {
 	// Initialize OpenTelemetry with Prometheus exporter
 	// Create a Prometheus registry and use it with the exporter
 	reg := prom.NewRegistry()
-	exporter, err := prometheus.New(prometheus.WithRegisterer(reg))
+	// WithoutUnits keeps metric names like rgrpc_call_total_ms (no _milliseconds suffix)
+	exporter, err := prometheus.New(prometheus.WithRegisterer(reg), prometheus.WithoutUnits())
 	if err != nil {
 		log.Fatalf("Failed to create Prometheus exporter: %v", err)
 	}
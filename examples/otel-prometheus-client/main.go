@@ -18,7 +18,8 @@ import (
 func main() {
 	// Initialize OpenTelemetry with Prometheus exporter
 	reg := prom.NewRegistry()
-	exporter, err := otelprom.New(otelprom.WithRegisterer(reg))
+	// WithoutUnits keeps metric names like rgrpc_call_total_ms (no _milliseconds suffix)
+	exporter, err := otelprom.New(otelprom.WithRegisterer(reg), otelprom.WithoutUnits())
 	if err != nil {
 		log.Fatalf("Failed to create Prometheus exporter: %v", err)
 	}
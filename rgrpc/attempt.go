@@ -0,0 +1,106 @@
+package rgrpc
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/stats"
+	"google.golang.org/grpc/status"
+)
+
+// Values of the attempt_type attribute on attempt_* metrics.
+const (
+	attemptInitial = "initial"
+	attemptRetry   = "retry" // rgrpc's own retries and gRPC's transparent retries
+	attemptHedge   = "hedge"
+)
+
+type attemptStateKey struct{}
+
+// attemptState holds the phase timestamps of one attempt. callState keeps the
+// first of each event across attempts; this keeps them per attempt, so retries
+// are not blended into the first attempt's phases. Created in TagRPC, which
+// gRPC calls once per attempt.
+type attemptState struct {
+	kind      string
+	beginUnix int64
+
+	// set by the stats handler
+	outHeaderUnix  atomic.Int64
+	outPayloadUnix atomic.Int64
+	inPayloadUnix  atomic.Int64
+	remoteIP       atomic.Value // string
+	responded      atomic.Bool  // headers or trailers received
+}
+
+func newAttemptState(st *callState) *attemptState {
+	a := &attemptState{kind: attemptInitial, beginUnix: unixNow()}
+	switch {
+	case st.isHedge:
+		a.kind = attemptHedge
+	case st.tagged.Add(1) > 1:
+		a.kind = attemptRetry
+	}
+	return a
+}
+
+// attemptPhases is the latency breakdown of one attempt.
+type attemptPhases struct {
+	total           time.Duration // Begin -> End
+	streamEstablish time.Duration // Begin -> OutHeader
+	responseWait    time.Duration // OutPayload -> End (unary) or first InPayload (streams)
+}
+
+// attemptStateFrom returns the attempt state TagRPC stored in ctx, if any.
+func attemptStateFrom(ctx context.Context) *attemptState {
+	a, _ := ctx.Value(attemptStateKey{}).(*attemptState)
+	return a
+}
+
+func (a *attemptState) phases(streaming bool, endUnix int64) attemptPhases {
+	p := attemptPhases{total: time.Duration(endUnix - a.beginUnix)}
+	if oh := a.outHeaderUnix.Load(); oh > 0 {
+		p.streamEstablish = time.Duration(oh - a.beginUnix)
+	}
+	if op := a.outPayloadUnix.Load(); op > 0 {
+		end := endUnix
+		if ip := a.inPayloadUnix.Load(); streaming && ip > 0 {
+			end = ip
+		}
+		if end >= op {
+			p.responseWait = time.Duration(end - op)
+		}
+	}
+	return p
+}
+
+// endAttempt records the attempt_* metrics of a finished attempt, if enabled,
+// and feeds its
+// result to the circuit breaker and outlier detector of the backend it ran on,
+// so a call that fails on one backend and succeeds on another counts against
+// the first only.
+func (h *hooks) endAttempt(ctx context.Context, st *callState, a *attemptState, ev *stats.End, endUnix int64) {
+	remoteIP, _ := a.remoteIP.Load().(string)
+	if remoteIP == "" {
+		remoteIP = "unknown"
+	}
+	code := status.Code(ev.Error)
+	p := a.phases(st.isStreaming, endUnix)
+	if h.attemptMetrics {
+		h.metrics.recordAttempt(ctx, st.method, remoteIP, code, a.kind, p)
+	}
+
+	// An attempt cancelled by the caller, or by a hedge that won, says nothing
+	// about its backend.
+	if code == codes.Canceled {
+		return
+	}
+	if h.breakers != nil {
+		h.breakers.record(ctx, remoteIP, h.breakers.failed(code, p.total))
+	}
+	if h.outliers != nil {
+		h.outliers.record(remoteIP, code, p.responseWait)
+	}
+}
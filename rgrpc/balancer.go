@@ -0,0 +1,202 @@
+package rgrpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"sync/atomic"
+
+	"google.golang.org/grpc/balancer"
+	"google.golang.org/grpc/balancer/pickfirst"
+	"google.golang.org/grpc/balancer/roundrobin"
+	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/resolver"
+	"google.golang.org/grpc/serviceconfig"
+)
+
+// Balancer names registered by rgrpc. They wrap the gRPC policies that
+// EnableClientSideLB switches between and behave identically, except that
+// their pickers timestamp the pick into the call's callState.
+const (
+	roundRobinBalancerName = "rgrpc_round_robin"
+	pickFirstBalancerName  = "rgrpc_pick_first"
+)
+
+func init() {
+	balancer.Register(&timedBalancerBuilder{name: roundRobinBalancerName, inner: roundrobin.Name})
+	balancer.Register(&timedBalancerBuilder{name: pickFirstBalancerName, inner: pickfirst.Name})
+}
+
+// serviceConfigFor returns the default service config selecting rgrpc's
+// wrapped round_robin or pick_first policy, or its p2c_ewma policy.
+func serviceConfigFor(clientSideLB bool, policy string) string {
+	if clientSideLB {
+		if policy == LoadBalancingP2CEWMA {
+			return `{"loadBalancingConfig":[{"` + p2cBalancerName + `":{}}]}`
+		}
+		// Round-robin is recommended for headless/endpoint-list discovery.
+		return `{"loadBalancingConfig":[{"` + roundRobinBalancerName + `":{}}]}`
+	}
+	return `{"loadBalancingConfig":[{"` + pickFirstBalancerName + `":{}}]}`
+}
+
+// timedBalancerBuilder builds the inner policy with a ClientConn wrapper that
+// wraps every picker the policy produces.
+type timedBalancerBuilder struct {
+	name  string
+	inner string
+}
+
+func (b *timedBalancerBuilder) Name() string { return b.name }
+
+func (b *timedBalancerBuilder) Build(cc balancer.ClientConn, opts balancer.BuildOptions) balancer.Balancer {
+	// The inner balancer is returned as-is so optional interfaces it implements
+	// (e.g. ExitIdler) keep working.
+	return balancer.Get(b.inner).Build(&timedBalancerCC{ClientConn: cc, ips: &subConnIPs{}}, opts)
+}
+
+func (b *timedBalancerBuilder) ParseConfig(js json.RawMessage) (serviceconfig.LoadBalancingConfig, error) {
+	if p, ok := balancer.Get(b.inner).(balancer.ConfigParser); ok {
+		return p.ParseConfig(js)
+	}
+	return nil, nil
+}
+
+type timedBalancerCC struct {
+	balancer.ClientConn
+	ips *subConnIPs
+}
+
+// NewSubConn records the IP of every subchannel so the picker can look up
+// its circuit breaker.
+func (c *timedBalancerCC) NewSubConn(addrs []resolver.Address, opts balancer.NewSubConnOptions) (balancer.SubConn, error) {
+	var sc balancer.SubConn
+	listener := opts.StateListener
+	opts.StateListener = func(s balancer.SubConnState) {
+		if s.ConnectivityState == connectivity.Shutdown {
+			c.ips.remove(sc)
+		}
+		if listener != nil {
+			listener(s)
+		}
+	}
+	sc, err := c.ClientConn.NewSubConn(addrs, opts)
+	if err != nil {
+		return nil, err
+	}
+	if len(addrs) > 0 {
+		c.ips.add(sc, hostFromAddr(addrs[0].Addr))
+	}
+	return sc, nil
+}
+
+func (c *timedBalancerCC) UpdateState(s balancer.State) {
+	if s.Picker != nil {
+		s.Picker = &timedPicker{inner: s.Picker, ips: c.ips}
+	}
+	c.ClientConn.UpdateState(s)
+}
+
+// subConnIPs maps the live subchannels of one balancer to their backend IP.
+type subConnIPs struct {
+	m sync.Map // balancer.SubConn -> string
+	n atomic.Int32
+}
+
+func (s *subConnIPs) add(sc balancer.SubConn, ip string) {
+	if _, loaded := s.m.Swap(sc, ip); !loaded {
+		s.n.Add(1)
+	}
+}
+
+func (s *subConnIPs) remove(sc balancer.SubConn) {
+	if _, loaded := s.m.LoadAndDelete(sc); loaded {
+		s.n.Add(-1)
+	}
+}
+
+func (s *subConnIPs) ip(sc balancer.SubConn) (string, bool) {
+	v, ok := s.m.Load(sc)
+	if !ok {
+		return "", false
+	}
+	return v.(string), true
+}
+
+// timedPicker records when a call got its first subchannel, and whether it had
+// to wait because no subchannel was ready (gRPC re-picks on the next picker
+// update when Pick returns ErrNoSubConnAvailable). It also steers hedges away
+// from the subchannel of the call they duplicate, and all calls away from
+// backends whose circuit breaker is open or that outlier detection ejected.
+type timedPicker struct {
+	inner balancer.Picker
+	ips   *subConnIPs
+}
+
+// hedgeRepicks bounds how often a hedge's pick is retried to get a different
+// subchannel; with a single ready subchannel the hedge uses it anyway.
+const hedgeRepicks = 3
+
+// repicks bounds how often a pick is retried. Round robin moves one
+// subchannel per pick, so with circuit breakers or outlier detection every
+// subchannel is tried once before a skipped one is used anyway.
+func (p *timedPicker) repicks(st *callState) int {
+	if st.breakers != nil || st.outliers != nil {
+		return max(hedgeRepicks, int(p.ips.n.Load()))
+	}
+	return hedgeRepicks
+}
+
+// skip reports whether the pick of sc should be retried for st.
+func (p *timedPicker) skip(ctx context.Context, st *callState, sc balancer.SubConn) bool {
+	if st.avoid != nil && sc == st.avoid.sc {
+		return true
+	}
+	if st.breakers == nil && st.outliers == nil {
+		return false
+	}
+	ip, ok := p.ips.ip(sc)
+	if !ok {
+		return false
+	}
+	// Ejection is checked first so that a skipped pick does not use up a
+	// half-open breaker's probe.
+	if st.outliers != nil && st.outliers.isEjected(ip) {
+		return true
+	}
+	return st.breakers != nil && !st.breakers.allow(ctx, ip)
+}
+
+// subConnRef wraps a SubConn so it can be stored in an atomic.Pointer.
+type subConnRef struct {
+	sc balancer.SubConn
+}
+
+func (p *timedPicker) Pick(info balancer.PickInfo) (balancer.PickResult, error) {
+	res, err := p.inner.Pick(info)
+
+	st, _ := info.Ctx.Value(callStateKey{}).(*callState)
+	if st == nil {
+		return res, err
+	}
+	if err == nil && (st.avoid != nil || st.breakers != nil || st.outliers != nil) {
+		for i, n := 0, p.repicks(st); i < n && err == nil && p.skip(info.Ctx, st, res.SubConn); i++ {
+			if res.Done != nil {
+				res.Done(balancer.DoneInfo{})
+			}
+			res, err = p.inner.Pick(info)
+		}
+	}
+
+	switch {
+	case err == nil:
+		st.pickUnix.CompareAndSwap(0, unixNow())
+		if st.hedgeable && st.subConn.Load() == nil {
+			st.subConn.Store(&subConnRef{sc: res.SubConn})
+		}
+	case errors.Is(err, balancer.ErrNoSubConnAvailable):
+		st.pickBlocked.Store(true)
+	}
+	return res, err
+}
@@ -0,0 +1,44 @@
+package rgrpc
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// TestPickWait verifies that the wrapped balancer records the pick time and
+// counts the first call on an idle client as blocked on a ready subchannel.
+func TestPickWait(t *testing.T) {
+	for _, lb := range []bool{false, true} {
+		addr := startHealthServer(t, nil)
+		reader := sdkmetric.NewManualReader()
+		cc, err := New(context.Background(), "passthrough:///"+addr,
+			WithTCPSampling(0),
+			WithClientSideLB(lb),
+			WithMeterProvider(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))),
+			WithDialOptions(grpc.WithTransportCredentials(insecure.NewCredentials())),
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		if _, err := healthpb.NewHealthClient(cc).Check(ctx, &healthpb.HealthCheckRequest{}); err != nil {
+			t.Fatal(err)
+		}
+		cancel()
+
+		if got := len(seriesAttrs(t, reader, "rgrpc.pick_wait_ms")); got != 1 {
+			t.Errorf("lb=%v: pick_wait_ms: expected 1 series, got %d", lb, got)
+		}
+		if got := int64Value(t, reader, "rgrpc.picks_blocked"); got != 1 {
+			t.Errorf("lb=%v: picks_blocked = %d, want 1", lb, got)
+		}
+		cc.Close()
+	}
+}
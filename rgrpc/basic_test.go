@@ -9,13 +9,23 @@ import (
 	"google.golang.org/grpc/stats"
 )
 
+// mustNewHooks creates hooks for cfg or fails the test.
+func mustNewHooks(tb testing.TB, cfg Config) *hooks {
+	tb.Helper()
+	h, err := newHooks(cfg)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return h
+}
+
 // TestStreamingFinalizeInStatsEnd verifies that streaming RPCs finalize in stats.End
 // and verify the structure doesn't cause panics.
 func TestStreamingFinalizeInStatsEnd(t *testing.T) {
 	cfg := DefaultConfig()
 	cfg.TCPMetricsInterval = 0 // Disable TCP sampling for faster test
 
-	h := newHooks(cfg)
+	h := mustNewHooks(t, cfg)
 	defer h.close()
 
 	st := h.pool.Get().(*callState)
@@ -65,7 +75,7 @@ func TestUnaryStreamingPathSeparation(t *testing.T) {
 	cfg := DefaultConfig()
 	cfg.TCPMetricsInterval = 0
 
-	h := newHooks(cfg)
+	h := mustNewHooks(t, cfg)
 	defer h.close()
 
 	// Test unary path: isStreaming should be false
@@ -17,7 +17,7 @@ func BenchmarkUnaryAllocs(b *testing.B) {
 	cfg.TCPMetricsInterval = 0 // Disable TCP sampling to measure only call path overhead
 	cfg.MetricPrefix = "bench"
 
-	h := newHooks(cfg)
+	h := mustNewHooks(b, cfg)
 	defer h.close()
 
 	ui := newUnaryInterceptor(h)
@@ -53,7 +53,7 @@ func BenchmarkUnaryLatency(b *testing.B) {
 	cfg.TCPMetricsInterval = 0
 	cfg.MetricPrefix = "bench"
 
-	h := newHooks(cfg)
+	h := mustNewHooks(b, cfg)
 	defer h.close()
 
 	ui := newUnaryInterceptor(h)
@@ -0,0 +1,162 @@
+package rgrpc
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"google.golang.org/grpc/codes"
+)
+
+// Breaker states: the value of circuit_breaker_state, and (by name) the state
+// attribute of circuit_breaker_transitions.
+const (
+	breakerClosed int32 = iota
+	breakerOpen
+	breakerHalfOpen
+)
+
+var breakerStateNames = [...]string{
+	breakerClosed:   "closed",
+	breakerOpen:     "open",
+	breakerHalfOpen: "half_open",
+}
+
+// breakerSet holds the circuit breakers of one client, keyed by remote IP.
+// Only backends that recently failed have a breaker: a success against a
+// backend without one costs a map lookup, and a breaker is dropped once its
+// backend recovers, so the set does not grow with backend churn.
+type breakerSet struct {
+	threshold    int
+	failCodes    map[codes.Code]bool
+	slow         time.Duration
+	openDuration time.Duration
+	probes       int
+	met          *metrics
+
+	m sync.Map // remote IP -> *breaker
+}
+
+type breaker struct {
+	state atomic.Int32 // read without mu by allow in the closed state
+
+	mu        sync.Mutex
+	failures  int   // consecutive, while closed
+	sinceUnix int64 // when the breaker opened, or became half-open
+	admitted  int   // probes let through while half-open
+	succeeded int   // probes that succeeded
+}
+
+// newBreakerSet returns nil when circuit breaking is disabled.
+func newBreakerSet(cfg CircuitBreakerConfig, met *metrics) *breakerSet {
+	if !cfg.Enabled {
+		return nil
+	}
+	cfg = cfg.withDefaults()
+	s := &breakerSet{
+		threshold:    cfg.ConsecutiveFailures,
+		failCodes:    make(map[codes.Code]bool, len(cfg.FailureCodes)),
+		slow:         cfg.SlowCallThreshold,
+		openDuration: cfg.OpenDuration,
+		probes:       cfg.HalfOpenProbes,
+		met:          met,
+	}
+	for _, c := range cfg.FailureCodes {
+		s.failCodes[c] = true
+	}
+	return s
+}
+
+// failed reports whether an attempt that ended with code after total counts as
+// a failure of its backend.
+func (s *breakerSet) failed(code codes.Code, total time.Duration) bool {
+	return s.failCodes[code] || (s.slow > 0 && total > s.slow)
+}
+
+// allow reports whether a call may be sent to the backend at ip. A half-open
+// breaker admits up to HalfOpenProbes calls; if their results never arrive
+// (e.g. the call was re-picked elsewhere), new probes are admitted after
+// another OpenDuration.
+func (s *breakerSet) allow(ctx context.Context, ip string) bool {
+	v, ok := s.m.Load(ip)
+	if !ok {
+		return true
+	}
+	b := v.(*breaker)
+	if b.state.Load() == breakerClosed {
+		return true
+	}
+
+	now := unixNow()
+	b.mu.Lock()
+	state := b.state.Load()
+	if state == breakerOpen {
+		if time.Duration(now-b.sinceUnix) < s.openDuration {
+			b.mu.Unlock()
+			return false
+		}
+		state = breakerHalfOpen
+		b.state.Store(state)
+		b.sinceUnix, b.admitted, b.succeeded = now, 0, 0
+		defer s.met.recordBreakerTransition(ctx, ip, state)
+	}
+	if state == breakerHalfOpen && b.admitted >= s.probes && time.Duration(now-b.sinceUnix) >= s.openDuration {
+		b.sinceUnix, b.admitted, b.succeeded = now, 0, 0
+	}
+	ok = state != breakerHalfOpen || b.admitted < s.probes
+	if ok && state == breakerHalfOpen {
+		b.admitted++
+	}
+	b.mu.Unlock()
+	return ok
+}
+
+// record feeds the result of a finished attempt to the breaker of its backend.
+func (s *breakerSet) record(ctx context.Context, ip string, failed bool) {
+	if ip == "" || ip == "unknown" {
+		return
+	}
+	v, ok := s.m.Load(ip)
+	if !ok {
+		if !failed {
+			return
+		}
+		v, _ = s.m.LoadOrStore(ip, &breaker{})
+	}
+	b := v.(*breaker)
+
+	b.mu.Lock()
+	from := b.state.Load()
+	to := from
+	switch from {
+	case breakerClosed:
+		if failed {
+			if b.failures++; b.failures >= s.threshold {
+				to = breakerOpen
+			}
+		} else {
+			b.failures = 0
+		}
+	case breakerHalfOpen:
+		if failed {
+			to = breakerOpen
+		} else if b.succeeded++; b.succeeded >= s.probes {
+			to = breakerClosed
+		}
+	}
+	// Results of calls sent before the breaker opened are ignored while open.
+	if to != from {
+		b.state.Store(to)
+		b.sinceUnix, b.failures, b.admitted, b.succeeded = unixNow(), 0, 0, 0
+	}
+	healthy := to == breakerClosed && b.failures == 0
+	b.mu.Unlock()
+
+	if healthy {
+		s.m.CompareAndDelete(ip, b)
+	}
+	if to != from {
+		s.met.recordBreakerTransition(ctx, ip, to)
+	}
+}
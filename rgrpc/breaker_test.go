@@ -0,0 +1,192 @@
+package rgrpc
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/resolver"
+	"google.golang.org/grpc/resolver/manual"
+	"google.golang.org/grpc/status"
+)
+
+// startHealthServerOn starts a health server listening on ip whose calls fail
+// with code unless it is OK, and returns its address and a call counter.
+func startHealthServerOn(t *testing.T, ip string, code codes.Code) (string, *atomic.Int64) {
+	t.Helper()
+	var calls atomic.Int64
+	addr := startHealthServerWith(t, ip, func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		calls.Add(1)
+		if code != codes.OK {
+			return nil, status.Error(code, "bad backend")
+		}
+		return handler(ctx, req)
+	})
+	return addr, &calls
+}
+
+// TestCircuitBreakerSkipsOpenBackend verifies that once a backend's breaker
+// opens, the round-robin picker sends every call to the healthy backend.
+func TestCircuitBreakerSkipsOpenBackend(t *testing.T) {
+	goodAddr, _ := startHealthServerOn(t, "127.0.0.1", codes.OK)
+	badAddr, badCalls := startHealthServerOn(t, "127.0.0.2", codes.Unavailable)
+
+	r := manual.NewBuilderWithScheme("rgrpcbreaker")
+	r.InitialState(resolver.State{Addresses: []resolver.Address{{Addr: goodAddr}, {Addr: badAddr}}})
+
+	reader := sdkmetric.NewManualReader()
+	cc, err := New(context.Background(), "rgrpcbreaker:///svc",
+		WithTCPSampling(0),
+		WithClientSideLB(true),
+		WithResolver(r),
+		WithCircuitBreaker(CircuitBreakerConfig{ConsecutiveFailures: 2, OpenDuration: time.Minute}),
+		WithMeterProvider(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))),
+		WithDialOptions(grpc.WithTransportCredentials(insecure.NewCredentials())),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cc.Close()
+	client := healthpb.NewHealthClient(cc)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	for i := 0; i < 100 && badCalls.Load() < 2; i++ {
+		client.Check(ctx, &healthpb.HealthCheckRequest{})
+	}
+	if badCalls.Load() != 2 {
+		t.Fatalf("bad backend saw %d calls before the breaker opened, want 2", badCalls.Load())
+	}
+
+	for i := 0; i < 20; i++ {
+		if _, err := client.Check(ctx, &healthpb.HealthCheckRequest{}); err != nil {
+			t.Fatalf("call %d: %v", i, err)
+		}
+	}
+	if got := badCalls.Load(); got != 2 {
+		t.Errorf("bad backend saw %d calls, want 2", got)
+	}
+	if got := int64Value(t, reader, "rgrpc.circuit_breaker_state"); got != int64(breakerOpen) {
+		t.Errorf("circuit_breaker_state = %d, want %d", got, breakerOpen)
+	}
+	if got := int64Value(t, reader, "rgrpc.circuit_breaker_transitions"); got != 1 {
+		t.Errorf("circuit_breaker_transitions = %d, want 1", got)
+	}
+}
+
+// TestCircuitBreakerCountsRetriedAttempts verifies that a call which fails on
+// one backend and is retried on another counts against the first: its breaker
+// opens even though the calls succeed.
+func TestCircuitBreakerCountsRetriedAttempts(t *testing.T) {
+	goodAddr, _ := startHealthServerOn(t, "127.0.0.1", codes.OK)
+	badAddr, badCalls := startHealthServerOn(t, "127.0.0.2", codes.Unavailable)
+
+	r := manual.NewBuilderWithScheme("rgrpcbreakerretry")
+	r.InitialState(resolver.State{Addresses: []resolver.Address{{Addr: goodAddr}, {Addr: badAddr}}})
+
+	reader := sdkmetric.NewManualReader()
+	cc, err := New(context.Background(), "rgrpcbreakerretry:///svc",
+		WithTCPSampling(0),
+		WithClientSideLB(true),
+		WithResolver(r),
+		WithRetryPolicy(RetryPolicy{MaxAttempts: 2, InitialBackoff: time.Millisecond}),
+		WithCircuitBreaker(CircuitBreakerConfig{ConsecutiveFailures: 2, OpenDuration: time.Minute}),
+		WithMeterProvider(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))),
+		WithDialOptions(grpc.WithTransportCredentials(insecure.NewCredentials())),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cc.Close()
+	client := healthpb.NewHealthClient(cc)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	// Until both subchannels are ready, a retry can land on the bad backend
+	// again and the call fails, so only later calls must succeed.
+	for i := 0; i < 20; i++ {
+		client.Check(ctx, &healthpb.HealthCheckRequest{})
+	}
+	if got := badCalls.Load(); got != 2 {
+		t.Errorf("bad backend saw %d attempts, want 2 before its breaker opened", got)
+	}
+	if got := int64Value(t, reader, "rgrpc.circuit_breaker_state"); got != int64(breakerOpen) {
+		t.Errorf("circuit_breaker_state = %d, want %d", got, breakerOpen)
+	}
+	for i := 0; i < 20; i++ {
+		if _, err := client.Check(ctx, &healthpb.HealthCheckRequest{}); err != nil {
+			t.Fatalf("call %d after the breaker opened: %v", i, err)
+		}
+	}
+}
+
+func TestBreakerStates(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.TCPMetricsInterval = 0
+	h := mustNewHooks(t, cfg)
+	defer h.close()
+
+	s := newBreakerSet(CircuitBreakerConfig{
+		Enabled:             true,
+		ConsecutiveFailures: 3,
+		SlowCallThreshold:   time.Second,
+		OpenDuration:        20 * time.Millisecond,
+		HalfOpenProbes:      2,
+	}, h.metrics)
+	ctx := context.Background()
+	const ip = "10.0.0.1"
+
+	if !s.failed(codes.Unavailable, 0) || !s.failed(codes.OK, 2*time.Second) || s.failed(codes.NotFound, 0) {
+		t.Error("failure classification")
+	}
+
+	// A success between failures resets the count.
+	s.record(ctx, ip, true)
+	s.record(ctx, ip, true)
+	s.record(ctx, ip, false)
+	if _, ok := s.m.Load(ip); ok {
+		t.Error("breaker kept after its backend recovered")
+	}
+	for i := 0; i < 3; i++ {
+		if !s.allow(ctx, ip) {
+			t.Fatalf("rejected while closed after %d failures", i)
+		}
+		s.record(ctx, ip, true)
+	}
+	if s.allow(ctx, ip) {
+		t.Fatal("allowed while open")
+	}
+
+	// Half-open: two probes, then rejection until they report.
+	time.Sleep(30 * time.Millisecond)
+	if !s.allow(ctx, ip) || !s.allow(ctx, ip) || s.allow(ctx, ip) {
+		t.Fatal("half-open must admit exactly HalfOpenProbes calls")
+	}
+	s.record(ctx, ip, false)
+	s.record(ctx, ip, true)
+	if s.allow(ctx, ip) {
+		t.Fatal("a failed probe must reopen the breaker")
+	}
+
+	time.Sleep(30 * time.Millisecond)
+	s.allow(ctx, ip)
+	s.allow(ctx, ip)
+	s.record(ctx, ip, false)
+	s.record(ctx, ip, false)
+	if _, ok := s.m.Load(ip); ok {
+		t.Error("breaker kept after its probes succeeded")
+	}
+	if !s.allow(ctx, ip) {
+		t.Error("rejected after closing")
+	}
+
+	if newBreakerSet(CircuitBreakerConfig{}, h.metrics) != nil {
+		t.Error("expected nil breaker set when disabled")
+	}
+}
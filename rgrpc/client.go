@@ -4,9 +4,13 @@ import (
 	"context"
 	"fmt"
 	"net"
+	"reflect"
+	"runtime"
+	"strings"
 	"sync"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
 )
 
 // ClientConn wraps grpc.ClientConn and ensures hooks are cleaned up on Close().
@@ -53,13 +57,48 @@ func NewClient(target string, opts ...grpc.DialOption) (*ClientConn, error) {
 // Use this when you need custom settings (e.g., EnableClientSideLB for Kubernetes
 // headless services, or custom metric prefix).
 //
-// The ctx parameter is reserved for future use and can be context.Background().
+// The ctx parameter bounds the connection warm-up when cfg.BlockingWarmup is set;
+// otherwise it is unused and can be context.Background().
+//
+// New with functional options is the preferred constructor for new code;
+// NewClientWithConfig is kept for compatibility.
 func NewClientWithConfig(ctx context.Context, target string, cfg Config, opts ...grpc.DialOption) (*ClientConn, error) {
+	return newClient(ctx, target, cfg, opts)
+}
+
+// New creates a new gRPC client connection configured by functional options.
+// Options are applied on top of DefaultConfig(); the process-wide
+// SetDefaultConfig value is not consulted.
+//
+//	cc, err := rgrpc.New(ctx, "dns:///my-service:50051",
+//	    rgrpc.WithMetricPrefix("billing"),
+//	    rgrpc.WithClientSideLB(true),
+//	    rgrpc.WithDialOptions(grpc.WithTransportCredentials(insecure.NewCredentials())),
+//	)
+//
+// The ctx parameter bounds the connection warm-up when WithBlockingWarmup is used.
+func New(ctx context.Context, target string, opts ...Option) (*ClientConn, error) {
+	o := clientOptions{cfg: DefaultConfig()}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return newClient(ctx, target, o.cfg, o.dialOpts)
+}
+
+func newClient(ctx context.Context, target string, cfg Config, opts []grpc.DialOption) (*ClientConn, error) {
 	if err := cfg.Validate(); err != nil {
 		return nil, fmt.Errorf("invalid config: %w", err)
 	}
+	for _, o := range opts {
+		if isContextDialer(o) {
+			return nil, fmt.Errorf("invalid dial options: grpc.WithContextDialer replaces rgrpc's dialer and disables connection tracking; use WithDialer (Config.Dialer) instead")
+		}
+	}
 
-	h := newHooks(cfg)
+	h, err := newHooks(cfg)
+	if err != nil {
+		return nil, err
+	}
 
 	// Our options go first so they wrap as much as possible.
 	our := []grpc.DialOption{
@@ -71,12 +110,26 @@ func NewClientWithConfig(ctx context.Context, target string, cfg Config, opts ..
 		}),
 	}
 
-	if cfg.EnableClientSideLB {
-		// Round-robin is recommended for headless/endpoint-list discovery.
-		our = append(our, grpc.WithDefaultServiceConfig(`{"loadBalancingConfig":[{"round_robin":{}}]}`))
+	// gRPC uses the first resolver registered for a scheme, so the wrapper
+	// must come before the raw custom resolver.
+	if cfg.EnableResolverMetrics {
+		if rb := resolverBuilderFor(target, cfg.Resolver); rb != nil {
+			our = append(our, grpc.WithResolvers(newTimedResolverBuilder(rb, h.metrics, target)))
+		}
+	}
+	if cfg.Resolver != nil {
+		our = append(our, grpc.WithResolvers(cfg.Resolver))
+	}
+
+	if cfg.TransportCredentials != nil {
+		our = append(our, grpc.WithTransportCredentials(newTimedCredentials(cfg.TransportCredentials, h.metrics)))
 	}
 
-	// NOTE: grpc.NewClient does not do I/O; it lazily connects. Preserve that.
+	// rgrpc's balancers (see balancer.go and p2c.go) time the pick.
+	our = append(our, grpc.WithDefaultServiceConfig(serviceConfigFor(cfg.EnableClientSideLB, cfg.LoadBalancingPolicy)))
+
+	// NOTE: grpc.NewClient does not do I/O; it lazily connects. Preserve that
+	// unless the caller explicitly asked for a blocking warm-up.
 	all := append(our, opts...)
 	cc, err := grpc.NewClient(target, all...)
 	if err != nil {
@@ -84,9 +137,62 @@ func NewClientWithConfig(ctx context.Context, target string, cfg Config, opts ..
 		return nil, err
 	}
 
-	_ = ctx
-	return &ClientConn{
+	c := &ClientConn{
 		ClientConn: cc,
 		hooks:      h,
-	}, nil
+	}
+	h.target = target
+	registerDebugClient(h)
+
+	if cfg.BlockingWarmup {
+		if err := warmup(ctx, cc); err != nil {
+			_ = c.Close()
+			return nil, fmt.Errorf("warm-up of %q: %w", target, err)
+		}
+	}
+	return c, nil
+}
+
+// isContextDialer reports whether opt was made by grpc.WithContextDialer (or
+// grpc.WithDialer, which calls it). Dial options are opaque, so it looks at the
+// name of the function the option applies.
+func isContextDialer(opt grpc.DialOption) bool {
+	v := reflect.ValueOf(opt)
+	if v.Kind() == reflect.Pointer {
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return false
+	}
+	for i := 0; i < v.NumField(); i++ {
+		f := v.Field(i)
+		if f.Kind() != reflect.Func || f.IsNil() {
+			continue
+		}
+		if fn := runtime.FuncForPC(f.Pointer()); fn != nil && strings.HasPrefix(fn.Name(), "google.golang.org/grpc.WithContextDialer.") {
+			return true
+		}
+	}
+	return false
+}
+
+// warmup triggers a connection attempt and blocks until cc is READY or ctx is done.
+// TRANSIENT_FAILURE is not treated as fatal: gRPC keeps reconnecting with backoff,
+// and the caller's deadline decides how long that is acceptable.
+func warmup(ctx context.Context, cc *grpc.ClientConn) error {
+	cc.Connect()
+	for {
+		s := cc.GetState()
+		switch s {
+		case connectivity.Ready:
+			return nil
+		case connectivity.Shutdown:
+			return fmt.Errorf("connection shut down")
+		case connectivity.Idle:
+			cc.Connect()
+		}
+		if !cc.WaitForStateChange(ctx, s) {
+			return ctx.Err()
+		}
+	}
 }
@@ -1,9 +1,19 @@
 package rgrpc
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"log/slog"
+	"net"
 	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/resolver"
 )
 
 // Config controls the behavior of the resilient gRPC client.
@@ -11,8 +21,16 @@ type Config struct {
 	// EnableClientSideLB, when true, enables client-side round-robin load balancing.
 	// Recommended for Kubernetes headless services or endpoint discovery scenarios.
 	// When false (default), uses pick_first (appropriate for ClusterIP/VIP services).
+	// Both policies are wrapped by rgrpc to measure pick_wait_ms; a service
+	// config from the resolver or WithDialOptions takes precedence.
 	EnableClientSideLB bool
 
+	// LoadBalancingPolicy selects the policy used when EnableClientSideLB is
+	// true: LoadBalancingRoundRobin, or LoadBalancingP2CEWMA to prefer
+	// backends with lower recent response wait and fewer in-flight calls.
+	// Default: LoadBalancingRoundRobin
+	LoadBalancingPolicy string
+
 	// MetricPrefix is the prefix for all emitted metric names.
 	// Default: "rgrpc" (produces metrics like rgrpc_call_total_ms, rgrpc_tcp_rtt_ms, etc.)
 	MetricPrefix string
@@ -21,9 +39,634 @@ type Config struct {
 	// Set to 0 to disable periodic TCP sampling.
 	// Default: 5 minutes
 	//
-	// Note: TCP sampling is rate-limited (4 samples/sec) and has per-connection
-	// cooldown (10 seconds), so under load you'll sample a rotating subset of connections.
+	// Note: TCP sampling is rate-limited and has a per-connection cooldown (see
+	// TCPSampler), so under load you'll sample a rotating subset of connections.
 	TCPMetricsInterval time.Duration
+
+	// PerConnectionTCPMetrics, when true, additionally reports the latest
+	// TCP_INFO sample of every live connection as observable gauges
+	// (tcp_conn_*) labeled with conn_id (local port and remote address), so
+	// connections to the same remote_ip are not blended. Series disappear when
+	// the connection closes. Cardinality grows with the number of connections.
+	// Default: false
+	PerConnectionTCPMetrics bool
+
+	// TCPSampler bounds the cost of TCP_INFO sampling (periodic and slow-call).
+	// Zero fields use the defaults.
+	TCPSampler TCPSamplerConfig
+
+	// Labels are constant attributes added to every metric emitted by the client,
+	// e.g. attribute.String("upstream", "billing"). Keys must not collide with the
+	// attributes rgrpc sets itself (method, remote_ip, grpc_code, error_class).
+	Labels []attribute.KeyValue
+
+	// BlockingWarmup, when true, makes the constructor start connecting immediately
+	// and wait until the connection is READY. The wait is bounded by the ctx passed
+	// to NewClientWithConfig/New; if ctx ends first, the client is closed and the
+	// constructor returns an error.
+	// Default: false (connect lazily on first RPC, like grpc.NewClient)
+	BlockingWarmup bool
+
+	// MeterProvider is the provider used to create this client's instruments.
+	// When nil, the global provider (otel.GetMeterProvider) is used. The global
+	// provider is a delegate: clients created before otel.SetMeterProvider is
+	// called start recording into the real provider as soon as it is set, so
+	// construction order does not matter. Only the first SetMeterProvider call
+	// is delegated to, as with any OTel instrumentation.
+	MeterProvider metric.MeterProvider
+
+	// Dialer, when set, establishes connections instead of rgrpc's default
+	// net.Dialer (e.g. a SOCKS proxy, unix socket, bufconn, or a net.Dialer with
+	// Control hooks). rgrpc still wraps the returned net.Conn to track it and
+	// finds the TCP socket underneath wrappers that expose NetConn() (such as
+	// *tls.Conn), so TCP metrics keep working.
+	//
+	// grpc.WithContextDialer (and grpc.WithDialer) dial options are rejected
+	// by New: they would replace rgrpc's dialer entirely and connections would
+	// no longer be tracked.
+	Dialer func(ctx context.Context, addr string) (net.Conn, error)
+
+	// TransportCredentials, when set, are installed for the client (like
+	// grpc.WithTransportCredentials) wrapped so that every client handshake is
+	// timed into tls_handshake_ms, labeled with TLS version, cipher and ALPN.
+	// Credentials passed directly via grpc.WithTransportCredentials still work
+	// but their handshakes are not measured.
+	TransportCredentials credentials.TransportCredentials
+
+	// EnableResolverMetrics, when true, wraps the resolver for the target's scheme
+	// (dns, passthrough, or custom) to emit resolution latency, errors, address
+	// counts and address churn (resolver_* metrics). The wrapper is installed
+	// ahead of the dial options, so a builder passed with grpc.WithResolvers
+	// for a globally registered scheme is shadowed by the wrapped global
+	// builder; pass such a builder as Resolver instead.
+	// Default: false
+	EnableResolverMetrics bool
+
+	// Resolver, when set, is installed for this client (like grpc.WithResolvers)
+	// and is the resolver wrapped for metrics when the target uses its scheme.
+	// With EnableResolverMetrics, use it instead of grpc.WithResolvers.
+	Resolver resolver.Builder
+
+	// HistogramBuckets overrides the explicit bucket boundaries of individual
+	// histograms. Keys are metric names without the prefix (e.g. "call_total_ms",
+	// "tcp_cwnd"); values must be strictly increasing. Histograms not listed use
+	// rgrpc's per-metric defaults (latency buckets from 0.05ms to 60s, segment
+	// buckets for tcp_cwnd, 1..10 for attempts_per_call).
+	//
+	// For base-2 exponential histograms instead of explicit buckets, register
+	// ExponentialHistogramView on the SDK MeterProvider.
+	HistogramBuckets map[string][]float64
+
+	// EnableTracing, when true, creates an OpenTelemetry client span per RPC and a
+	// child span per attempt. Phase timestamps (out_header, out_payload, in_header,
+	// in_payload) are attached to the call span as events, together with the
+	// remote IP, attempt count and status. Attempt span contexts are propagated
+	// to the server as W3C traceparent/tracestate metadata.
+	// Default: false
+	EnableTracing bool
+
+	// SlowCall configures which calls trigger an on-demand TCP_INFO sample of
+	// their connection (tagged trigger="slow_call") and, with SlowCallLog, a
+	// log record.
+	// Default: disabled
+	SlowCall SlowCallConfig
+
+	// SlowCallLog, when its Logger is set, logs one structured record per slow
+	// call (as defined by SlowCall, which must then set a threshold or
+	// percentile) with its phase breakdown, sampled and rate limited.
+	// Default: disabled
+	SlowCallLog SlowCallLogConfig
+
+	// OnConnectionClosed, when set, is called with a summary of every tracked
+	// connection as it closes, including a final TCP_INFO snapshot (lifetime
+	// min RTT, total retransmits, bytes sent/acked). It runs on the goroutine
+	// closing the connection and must not block.
+	OnConnectionClosed func(ConnectionSummary)
+
+	// Retry configures rgrpc's own retries of unary calls: which status codes
+	// are retried, how many times, with what backoff, and a retry budget that
+	// caps retries as a fraction of traffic. Streams are never retried by rgrpc.
+	// Unlike gRPC's service-config retries, every attempt is visible to the
+	// stats handler and recorded in the attempt_* metrics.
+	// Default: disabled
+	Retry RetryConfig
+
+	// EnableAttemptMetrics, when true, records the attempt_* histograms for
+	// every attempt. They are always recorded when Retry or Hedging is
+	// configured; without either, a call has one attempt (plus gRPC's
+	// transparent retries) and the call metrics already describe it.
+	// Default: false
+	EnableAttemptMetrics bool
+
+	// Hedging sends a second copy of a slow call of an idempotent unary method
+	// to another backend once the first has not responded within the method's
+	// observed latency percentile, and uses whichever response comes first.
+	// Default: disabled (no methods)
+	Hedging HedgingConfig
+
+	// CircuitBreaker keeps a breaker per backend remote_ip, fed by the status
+	// and latency of every finished call. With EnableClientSideLB, the
+	// round-robin picker skips backends whose breaker is open.
+	// Default: disabled
+	CircuitBreaker CircuitBreakerConfig
+
+	// OutlierDetection periodically compares each backend's success rate and
+	// p99 response_wait_ms with the rest of the fleet and ejects outliers from
+	// client-side load balancing for an increasing time.
+	// Default: disabled
+	OutlierDetection OutlierDetectionConfig
+
+	// CallObserver, when set, receives a CallRecord for every finished call
+	// (method, addresses, phase durations, attempts, status, bytes), e.g. to
+	// feed logging, SLO or anomaly pipelines. Records are delivered from a
+	// queue on a separate goroutine; see CallObserver.
+	CallObserver CallObserver
+
+	// CallObserverQueueSize is how many records can wait for CallObserver
+	// before new ones are dropped (counted in call_records_dropped).
+	// Default: 1024
+	CallObserverQueueSize int
+
+	// EnableCallHistory, when true, keeps the slowest calls of the last few
+	// minutes and the most recent failed calls for DebugHandler. When false,
+	// DebugHandler still lists the client but without call history, and
+	// nothing is recorded on the call path.
+	// Default: false
+	EnableCallHistory bool
+
+	// TracerProvider is the provider used to create this client's tracer.
+	// When nil, the global provider (otel.GetTracerProvider) is used, with the
+	// same late-binding delegation as MeterProvider.
+	TracerProvider trace.TracerProvider
+}
+
+// TCPSamplerConfig configures the worker that takes TCP_INFO samples. Requests
+// it cannot serve are dropped and counted in tcp_samples_dropped by reason.
+type TCPSamplerConfig struct {
+	// RatePerSec is the sustained number of samples per second across all
+	// connections. With N connections and interval I, full coverage needs
+	// roughly N/I. A negative value removes the rate limit. Default: 4
+	RatePerSec float64
+
+	// Burst is the number of samples allowed at once above RatePerSec.
+	// Default: 8
+	Burst int
+
+	// Cooldown is the minimum time between two samples of the same connection.
+	// A negative value disables the cooldown. Default: 10 seconds
+	Cooldown time.Duration
+
+	// QueueSize is the number of pending sample requests; requests beyond it
+	// are dropped. Default: 512
+	QueueSize int
+
+	// Backend selects how periodic samples are taken. Slow-call samples always
+	// use getsockopt. Default: TCPSamplerGetsockopt
+	Backend TCPSamplerBackend
+}
+
+// TCPSamplerBackend is a way of reading TCP_INFO for periodic samples.
+type TCPSamplerBackend string
+
+const (
+	// TCPSamplerGetsockopt issues one getsockopt(TCP_INFO) per connection,
+	// subject to the sampler's rate limit and cooldown.
+	TCPSamplerGetsockopt TCPSamplerBackend = "getsockopt"
+
+	// TCPSamplerNetlink reads TCP_INFO for all connections with a single
+	// NETLINK_SOCK_DIAG dump per interval, matched to tracked connections by
+	// 4-tuple, so every connection is sampled on every tick with no rate limit.
+	// The dump covers every TCP socket in the network namespace, so its cost
+	// grows with the host's socket count. Linux only; where the dump fails
+	// (other platforms, seccomp), sampling falls back to getsockopt.
+	TCPSamplerNetlink TCPSamplerBackend = "netlink"
+)
+
+// Defaults for TCPSamplerConfig fields left zero.
+const (
+	defaultTCPSampleRate      = 4.0
+	defaultTCPSampleBurst     = 8
+	defaultTCPSampleCooldown  = 10 * time.Second
+	defaultTCPSampleQueueSize = 512
+)
+
+// withDefaults returns c with zero fields replaced by their defaults. Negative
+// RatePerSec and Cooldown are kept: they mean no limit.
+func (c TCPSamplerConfig) withDefaults() TCPSamplerConfig {
+	if c.RatePerSec == 0 {
+		c.RatePerSec = defaultTCPSampleRate
+	}
+	if c.Burst == 0 {
+		c.Burst = defaultTCPSampleBurst
+	}
+	if c.Cooldown == 0 {
+		c.Cooldown = defaultTCPSampleCooldown
+	}
+	if c.QueueSize == 0 {
+		c.QueueSize = defaultTCPSampleQueueSize
+	}
+	if c.Backend == "" {
+		c.Backend = TCPSamplerGetsockopt
+	}
+	return c
+}
+
+// SlowCallConfig defines when a call counts as slow. A call is slow if it
+// exceeds its absolute threshold (MethodThresholds, else Threshold) or the
+// Percentile of its method's recent calls; either condition can be disabled by
+// leaving it zero. The latency compared is the one recorded in call_total_ms
+// (end-to-end for unary, TTFB for streams).
+type SlowCallConfig struct {
+	// Threshold is an absolute latency above which every call is slow.
+	Threshold time.Duration
+
+	// MethodThresholds overrides Threshold for individual methods, keyed by
+	// full method name ("/pkg.Service/Method"). A zero value disables the
+	// absolute threshold for that method.
+	MethodThresholds map[string]time.Duration
+
+	// Percentile, between 0 and 1 (e.g. 0.99), compares each call against a
+	// rolling estimate of that percentile for its method, so one setting fits
+	// both fast and slow methods.
+	Percentile float64
+
+	// MinSamples is how many calls of a method must be seen before the
+	// Percentile condition applies. Default: 100
+	MinSamples int
+}
+
+// enabled reports whether c defines any slow-call condition.
+func (c SlowCallConfig) enabled() bool {
+	return c.Threshold > 0 || c.Percentile > 0 || len(c.MethodThresholds) > 0
+}
+
+// SlowCallLogConfig configures slow-call logging. Records that are sampled out
+// or rate limited are counted in slow_call_logs_suppressed by reason.
+type SlowCallLogConfig struct {
+	// Logger receives one record per logged slow call, at slog.LevelWarn.
+	// nil disables slow-call logging.
+	Logger *slog.Logger
+
+	// SampleRate is the fraction of slow calls considered for logging, in
+	// [0, 1]; 0 means the default. Default: 1
+	SampleRate float64
+
+	// RatePerSec is the sustained number of records per second, after
+	// sampling. Default: 10
+	RatePerSec float64
+
+	// Burst is the number of records that can be logged at once above
+	// RatePerSec. Default: 20
+	Burst int
+}
+
+// Default slow-call log limits.
+const (
+	defaultSlowCallLogRate  = 10
+	defaultSlowCallLogBurst = 20
+)
+
+// withDefaults returns c with zero fields replaced by their defaults.
+func (c SlowCallLogConfig) withDefaults() SlowCallLogConfig {
+	if c.SampleRate == 0 {
+		c.SampleRate = 1
+	}
+	if c.RatePerSec == 0 {
+		c.RatePerSec = defaultSlowCallLogRate
+	}
+	if c.Burst == 0 {
+		c.Burst = defaultSlowCallLogBurst
+	}
+	return c
+}
+
+// RetryConfig configures client-side retries. Policy applies to every unary
+// method unless MethodPolicies has an entry for it.
+type RetryConfig struct {
+	Policy RetryPolicy
+
+	// MethodPolicies overrides Policy, keyed by full method name
+	// ("/pkg.Service/Method") or by service ("/pkg.Service/"). An entry with
+	// MaxAttempts <= 1 disables retries for those methods.
+	MethodPolicies map[string]RetryPolicy
+
+	// Budget caps retries across all methods of the client.
+	Budget RetryBudget
+}
+
+// RetryPolicy describes how one method is retried. Zero fields other than
+// MaxAttempts use the defaults.
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of attempts, including the first.
+	// 0 or 1 disables retries.
+	MaxAttempts int
+
+	// RetryableCodes are the status codes that are retried.
+	// Default: Unavailable
+	RetryableCodes []codes.Code
+
+	// InitialBackoff is the delay before the first retry; each further retry
+	// waits BackoffMultiplier times longer, up to MaxBackoff.
+	// Defaults: 50ms, 1s, 2
+	InitialBackoff    time.Duration
+	MaxBackoff        time.Duration
+	BackoffMultiplier float64
+
+	// Jitter randomizes each backoff by up to this fraction in either
+	// direction, in [0, 1]. Default: 0.2
+	Jitter float64
+}
+
+// Default retry policy values.
+const (
+	defaultRetryInitialBackoff = 50 * time.Millisecond
+	defaultRetryMaxBackoff     = time.Second
+	defaultRetryMultiplier     = 2
+	defaultRetryJitter         = 0.2
+)
+
+// withDefaults returns p with zero fields replaced by their defaults.
+func (p RetryPolicy) withDefaults() RetryPolicy {
+	if len(p.RetryableCodes) == 0 {
+		p.RetryableCodes = []codes.Code{codes.Unavailable}
+	}
+	if p.InitialBackoff == 0 {
+		p.InitialBackoff = defaultRetryInitialBackoff
+	}
+	if p.MaxBackoff == 0 {
+		p.MaxBackoff = defaultRetryMaxBackoff
+	}
+	if p.BackoffMultiplier == 0 {
+		p.BackoffMultiplier = defaultRetryMultiplier
+	}
+	if p.Jitter == 0 {
+		p.Jitter = defaultRetryJitter
+	}
+	return p
+}
+
+func (p RetryPolicy) validate() error {
+	switch {
+	case p.MaxAttempts < 0:
+		return fmt.Errorf("MaxAttempts must be >= 0, got %d", p.MaxAttempts)
+	case p.InitialBackoff < 0:
+		return fmt.Errorf("InitialBackoff must be >= 0, got %v", p.InitialBackoff)
+	case p.MaxBackoff < 0:
+		return fmt.Errorf("MaxBackoff must be >= 0, got %v", p.MaxBackoff)
+	case p.BackoffMultiplier != 0 && p.BackoffMultiplier < 1:
+		return fmt.Errorf("BackoffMultiplier must be >= 1, got %v", p.BackoffMultiplier)
+	case p.Jitter < 0 || p.Jitter > 1:
+		return fmt.Errorf("Jitter must be in [0, 1], got %v", p.Jitter)
+	}
+	return nil
+}
+
+// RetryBudget is a token bucket shared by all calls of a client: every call
+// deposits Ratio tokens and every retry spends one, so over time retries stay
+// below Ratio of calls. The bucket starts full and holds at most MaxTokens,
+// which lets a quiet client retry a few calls. Retries denied by the budget
+// are counted in retries_throttled.
+type RetryBudget struct {
+	// Ratio is the fraction of calls that may be retried. Default: 0.1
+	Ratio float64
+
+	// MaxTokens bounds the retries that can be saved up. Default: 10
+	MaxTokens float64
+}
+
+// Default retry budget values.
+const (
+	defaultRetryBudgetRatio     = 0.1
+	defaultRetryBudgetMaxTokens = 10
+)
+
+// withDefaults returns b with zero fields replaced by their defaults.
+func (b RetryBudget) withDefaults() RetryBudget {
+	if b.Ratio == 0 {
+		b.Ratio = defaultRetryBudgetRatio
+	}
+	if b.MaxTokens == 0 {
+		b.MaxTokens = defaultRetryBudgetMaxTokens
+	}
+	return b
+}
+
+// HedgingConfig configures request hedging. Only the listed methods are
+// hedged, and only unary calls whose reply is a proto.Message. Once a method
+// has a hedging delay (after MinSamples calls), its calls are not retried by
+// Config.Retry: the hedge is their second attempt. Until then Config.Retry
+// applies to them as to any other method.
+type HedgingConfig struct {
+	// Methods are the idempotent methods that may be hedged, by full method
+	// name ("/pkg.Service/Method") or by service ("/pkg.Service/").
+	Methods []string
+
+	// Percentile of the method's recent call_total_ms after which the hedge
+	// is sent, between 0 and 1. Default: 0.95
+	Percentile float64
+
+	// MinSamples is how many calls of a method must be seen before it is
+	// hedged; until then there is no reliable delay. Default: 100
+	MinSamples int
+
+	// MinDelay and MaxDelay clamp the hedging delay. Zero means no bound.
+	MinDelay time.Duration
+	MaxDelay time.Duration
+
+	// Budget caps hedges as a fraction of hedgeable calls, like RetryBudget
+	// caps retries. Hedges denied by the budget are counted in
+	// hedges_throttled.
+	Budget RetryBudget
+}
+
+// Default hedging values.
+const (
+	defaultHedgePercentile = 0.95
+	defaultHedgeMinSamples = 100
+)
+
+// CircuitBreakerConfig configures the per-backend circuit breakers. A breaker
+// opens after ConsecutiveFailures failed attempts on its backend (retries and
+// hedges count against the backend they ran on), rejects picks for
+// OpenDuration, then lets HalfOpenProbes calls through: if they all succeed it
+// closes, if one fails it opens again. When every ready backend is
+// open the picker uses one anyway rather than failing the call.
+type CircuitBreakerConfig struct {
+	// Enabled turns the breakers on.
+	Enabled bool
+
+	// ConsecutiveFailures is how many failed attempts in a row open a breaker.
+	// Default: 5
+	ConsecutiveFailures int
+
+	// FailureCodes are the status codes that count as a failure.
+	// Default: Unavailable, DeadlineExceeded, Internal
+	FailureCodes []codes.Code
+
+	// SlowCallThreshold, when > 0, also counts attempts slower than it (by
+	// attempt_duration_ms) as failures.
+	SlowCallThreshold time.Duration
+
+	// OpenDuration is how long an open breaker rejects picks before it lets
+	// probes through. Default: 10s
+	OpenDuration time.Duration
+
+	// HalfOpenProbes is how many calls a half-open breaker admits, and how
+	// many must succeed to close it. Default: 1
+	HalfOpenProbes int
+}
+
+// Client-side load balancing policies (Config.LoadBalancingPolicy).
+const (
+	LoadBalancingRoundRobin = "round_robin"
+	LoadBalancingP2CEWMA    = "p2c_ewma"
+)
+
+// Default circuit breaker values.
+const (
+	defaultBreakerConsecutiveFailures = 5
+	defaultBreakerOpenDuration        = 10 * time.Second
+	defaultBreakerHalfOpenProbes      = 1
+)
+
+// withDefaults returns c with zero fields replaced by their defaults.
+func (c CircuitBreakerConfig) withDefaults() CircuitBreakerConfig {
+	if c.ConsecutiveFailures == 0 {
+		c.ConsecutiveFailures = defaultBreakerConsecutiveFailures
+	}
+	if c.FailureCodes == nil {
+		c.FailureCodes = []codes.Code{codes.Unavailable, codes.DeadlineExceeded, codes.Internal}
+	}
+	if c.OpenDuration == 0 {
+		c.OpenDuration = defaultBreakerOpenDuration
+	}
+	if c.HalfOpenProbes == 0 {
+		c.HalfOpenProbes = defaultBreakerHalfOpenProbes
+	}
+	return c
+}
+
+// OutlierDetectionConfig configures Envoy-style outlier detection. Every
+// Interval, the backends (by remote_ip) with at least MinRequests attempts in
+// the interval are compared, provided there are at least MinHosts of them
+// (retries and hedges count for the backend they ran on; cancelled attempts
+// are ignored):
+//
+//   - a backend whose success rate is below the fleet mean by more than
+//     SuccessRateStdevFactor standard deviations is a success-rate outlier;
+//   - a backend whose p99 response_wait_ms exceeds LatencyFactor times the
+//     fleet's median p99, and MinLatency, is a latency outlier.
+//
+// An outlier is ejected for BaseEjectionTime times the number of times it has
+// been ejected, up to MaxEjectionTime; the count decreases for every interval
+// a backend is not ejected. At most MaxEjectionPercent of the backends are
+// ejected at once, but always at least one. Ejected backends are skipped by
+// the client-side picker like open circuit breakers.
+type OutlierDetectionConfig struct {
+	// Enabled turns outlier detection on.
+	Enabled bool
+
+	// Interval between evaluations. Default: 10s
+	Interval time.Duration
+
+	// BaseEjectionTime is the first ejection time. Default: 30s
+	BaseEjectionTime time.Duration
+
+	// MaxEjectionTime caps the ejection time. Default: 300s
+	MaxEjectionTime time.Duration
+
+	// MaxEjectionPercent bounds the share of backends ejected at once.
+	// Default: 10
+	MaxEjectionPercent int
+
+	// MinHosts is how many backends with enough attempts are needed for a
+	// comparison. Default: 5
+	MinHosts int
+
+	// MinRequests is how many attempts a backend needs in an interval to be
+	// compared. Default: 100
+	MinRequests int
+
+	// SuccessRateStdevFactor sets the success-rate threshold; negative
+	// disables success-rate detection. Default: 1.9
+	SuccessRateStdevFactor float64
+
+	// LatencyFactor sets the latency threshold as a multiple of the median
+	// p99; negative disables latency detection. Default: 3
+	LatencyFactor float64
+
+	// MinLatency is the p99 below which a backend is never a latency
+	// outlier, so fast fleets are not judged on noise. Default: 10ms
+	MinLatency time.Duration
+
+	// FailureCodes are the status codes that count against the success
+	// rate. Default: Unavailable, DeadlineExceeded, Internal
+	FailureCodes []codes.Code
+
+	// OnEvent, when set, is called for every ejection and every return of
+	// an ejected backend. It runs on the detector's goroutine and must not
+	// block.
+	OnEvent func(OutlierEvent)
+}
+
+// Default outlier detection values.
+const (
+	defaultOutlierInterval           = 10 * time.Second
+	defaultOutlierBaseEjectionTime   = 30 * time.Second
+	defaultOutlierMaxEjectionTime    = 300 * time.Second
+	defaultOutlierMaxEjectionPercent = 10
+	defaultOutlierMinHosts           = 5
+	defaultOutlierMinRequests        = 100
+	defaultOutlierStdevFactor        = 1.9
+	defaultOutlierLatencyFactor      = 3
+	defaultOutlierMinLatency         = 10 * time.Millisecond
+)
+
+// withDefaults returns c with zero fields replaced by their defaults.
+func (c OutlierDetectionConfig) withDefaults() OutlierDetectionConfig {
+	if c.Interval == 0 {
+		c.Interval = defaultOutlierInterval
+	}
+	if c.BaseEjectionTime == 0 {
+		c.BaseEjectionTime = defaultOutlierBaseEjectionTime
+	}
+	if c.MaxEjectionTime == 0 {
+		c.MaxEjectionTime = max(defaultOutlierMaxEjectionTime, c.BaseEjectionTime)
+	}
+	if c.MaxEjectionPercent == 0 {
+		c.MaxEjectionPercent = defaultOutlierMaxEjectionPercent
+	}
+	if c.MinHosts == 0 {
+		c.MinHosts = defaultOutlierMinHosts
+	}
+	if c.MinRequests == 0 {
+		c.MinRequests = defaultOutlierMinRequests
+	}
+	if c.SuccessRateStdevFactor == 0 {
+		c.SuccessRateStdevFactor = defaultOutlierStdevFactor
+	}
+	if c.LatencyFactor == 0 {
+		c.LatencyFactor = defaultOutlierLatencyFactor
+	}
+	if c.MinLatency == 0 {
+		c.MinLatency = defaultOutlierMinLatency
+	}
+	if c.FailureCodes == nil {
+		c.FailureCodes = []codes.Code{codes.Unavailable, codes.DeadlineExceeded, codes.Internal}
+	}
+	return c
+}
+
+// reservedLabels are attribute keys rgrpc sets itself; user labels must not override them.
+var reservedLabels = map[attribute.Key]bool{
+	"method":       true,
+	"remote_ip":    true,
+	"grpc_code":    true,
+	"error_class":  true,
+	"trigger":      true,
+	"conn_id":      true,
+	"attempt_type": true,
+	"state":        true,
 }
 
 // Validate checks that the Config has valid values and returns an error if not.
@@ -36,15 +679,145 @@ func (c Config) Validate() error {
 		return fmt.Errorf("TCPMetricsInterval must be >= 0, got %v", c.TCPMetricsInterval)
 	}
 
+	if c.TCPSampler.Burst < 0 {
+		return fmt.Errorf("TCPSampler.Burst must be >= 0, got %d", c.TCPSampler.Burst)
+	}
+	if c.TCPSampler.QueueSize < 0 {
+		return fmt.Errorf("TCPSampler.QueueSize must be >= 0, got %d", c.TCPSampler.QueueSize)
+	}
+	switch c.TCPSampler.Backend {
+	case "", TCPSamplerGetsockopt, TCPSamplerNetlink:
+	default:
+		return fmt.Errorf("TCPSampler.Backend: unknown backend %q", c.TCPSampler.Backend)
+	}
+
+	if c.SlowCall.Threshold < 0 {
+		return fmt.Errorf("SlowCall.Threshold must be >= 0, got %v", c.SlowCall.Threshold)
+	}
+	if c.SlowCall.Percentile < 0 || c.SlowCall.Percentile >= 1 {
+		return fmt.Errorf("SlowCall.Percentile must be in [0, 1), got %v", c.SlowCall.Percentile)
+	}
+	if c.SlowCall.MinSamples < 0 {
+		return fmt.Errorf("SlowCall.MinSamples must be >= 0, got %d", c.SlowCall.MinSamples)
+	}
+	for method, d := range c.SlowCall.MethodThresholds {
+		if d < 0 {
+			return fmt.Errorf("SlowCall.MethodThresholds[%q] must be >= 0, got %v", method, d)
+		}
+	}
+
+	if c.SlowCallLog.Logger != nil && !c.SlowCall.enabled() {
+		return fmt.Errorf("SlowCallLog.Logger is set but SlowCall has no Threshold, Percentile or MethodThresholds, so no call would be logged")
+	}
+	if c.SlowCallLog.SampleRate < 0 || c.SlowCallLog.SampleRate > 1 {
+		return fmt.Errorf("SlowCallLog.SampleRate must be in [0, 1] (0 means 1), got %v", c.SlowCallLog.SampleRate)
+	}
+	if c.SlowCallLog.RatePerSec < 0 {
+		return fmt.Errorf("SlowCallLog.RatePerSec must be >= 0, got %v", c.SlowCallLog.RatePerSec)
+	}
+	if c.SlowCallLog.Burst < 0 {
+		return fmt.Errorf("SlowCallLog.Burst must be >= 0, got %d", c.SlowCallLog.Burst)
+	}
+
+	if err := c.Retry.Policy.validate(); err != nil {
+		return fmt.Errorf("Retry.Policy: %w", err)
+	}
+	for method, p := range c.Retry.MethodPolicies {
+		if err := p.validate(); err != nil {
+			return fmt.Errorf("Retry.MethodPolicies[%q]: %w", method, err)
+		}
+	}
+	if c.Retry.Budget.Ratio < 0 {
+		return fmt.Errorf("Retry.Budget.Ratio must be >= 0, got %v", c.Retry.Budget.Ratio)
+	}
+	if c.Retry.Budget.MaxTokens < 0 {
+		return fmt.Errorf("Retry.Budget.MaxTokens must be >= 0, got %v", c.Retry.Budget.MaxTokens)
+	}
+
+	if c.Hedging.Percentile < 0 || c.Hedging.Percentile >= 1 {
+		return fmt.Errorf("Hedging.Percentile must be in [0, 1), got %v", c.Hedging.Percentile)
+	}
+	if c.Hedging.MinSamples < 0 {
+		return fmt.Errorf("Hedging.MinSamples must be >= 0, got %d", c.Hedging.MinSamples)
+	}
+	if c.Hedging.MinDelay < 0 || c.Hedging.MaxDelay < 0 {
+		return fmt.Errorf("Hedging delays must be >= 0, got %v and %v", c.Hedging.MinDelay, c.Hedging.MaxDelay)
+	}
+	if c.Hedging.MaxDelay > 0 && c.Hedging.MinDelay > c.Hedging.MaxDelay {
+		return fmt.Errorf("Hedging.MinDelay %v exceeds MaxDelay %v", c.Hedging.MinDelay, c.Hedging.MaxDelay)
+	}
+	if c.Hedging.Budget.Ratio < 0 || c.Hedging.Budget.MaxTokens < 0 {
+		return errors.New("Hedging.Budget fields must be >= 0")
+	}
+
+	switch c.LoadBalancingPolicy {
+	case "", LoadBalancingRoundRobin, LoadBalancingP2CEWMA:
+	default:
+		return fmt.Errorf("LoadBalancingPolicy: unknown policy %q", c.LoadBalancingPolicy)
+	}
+
+	if c.CircuitBreaker.ConsecutiveFailures < 0 {
+		return fmt.Errorf("CircuitBreaker.ConsecutiveFailures must be >= 0, got %d", c.CircuitBreaker.ConsecutiveFailures)
+	}
+	if c.CircuitBreaker.SlowCallThreshold < 0 || c.CircuitBreaker.OpenDuration < 0 {
+		return fmt.Errorf("CircuitBreaker durations must be >= 0, got %v and %v", c.CircuitBreaker.SlowCallThreshold, c.CircuitBreaker.OpenDuration)
+	}
+	if c.CircuitBreaker.HalfOpenProbes < 0 {
+		return fmt.Errorf("CircuitBreaker.HalfOpenProbes must be >= 0, got %d", c.CircuitBreaker.HalfOpenProbes)
+	}
+
+	od := c.OutlierDetection
+	if od.Interval < 0 || od.BaseEjectionTime < 0 || od.MaxEjectionTime < 0 || od.MinLatency < 0 {
+		return errors.New("OutlierDetection durations must be >= 0")
+	}
+	if od.MaxEjectionPercent < 0 || od.MaxEjectionPercent > 100 {
+		return fmt.Errorf("OutlierDetection.MaxEjectionPercent must be in [0, 100], got %d", od.MaxEjectionPercent)
+	}
+	if od.MinHosts < 0 || od.MinRequests < 0 {
+		return fmt.Errorf("OutlierDetection.MinHosts and MinRequests must be >= 0, got %d and %d", od.MinHosts, od.MinRequests)
+	}
+
+	if c.CallObserverQueueSize < 0 {
+		return fmt.Errorf("CallObserverQueueSize must be >= 0, got %d", c.CallObserverQueueSize)
+	}
+
+	for name, bounds := range c.HistogramBuckets {
+		if _, ok := defaultBuckets[name]; !ok {
+			return fmt.Errorf("HistogramBuckets: unknown histogram %q", name)
+		}
+		for i := 1; i < len(bounds); i++ {
+			if bounds[i] <= bounds[i-1] {
+				return fmt.Errorf("HistogramBuckets[%q]: boundaries must be strictly increasing", name)
+			}
+		}
+	}
+
+	for _, kv := range c.Labels {
+		if !kv.Valid() {
+			return fmt.Errorf("invalid label %q", kv.Key)
+		}
+		if reservedLabels[kv.Key] {
+			return fmt.Errorf("label %q is reserved", kv.Key)
+		}
+	}
+
 	return nil
 }
 
 // DefaultConfig returns a Config with sensible production defaults.
-// MetricPrefix is "rgrpc", EnableClientSideLB is false, and TCPMetricsInterval is 5 minutes.
+// MetricPrefix is "rgrpc", EnableClientSideLB is false, TCPMetricsInterval is 5 minutes
+// (4 samples/sec, burst 8, 10s cooldown per connection), and resolver metrics are enabled.
 func DefaultConfig() Config {
 	return Config{
 		EnableClientSideLB: false,
 		MetricPrefix:       "rgrpc",
 		TCPMetricsInterval: 5 * time.Minute,
+		TCPSampler: TCPSamplerConfig{
+			RatePerSec: defaultTCPSampleRate,
+			Burst:      defaultTCPSampleBurst,
+			Cooldown:   defaultTCPSampleCooldown,
+			QueueSize:  defaultTCPSampleQueueSize,
+			Backend:    TCPSamplerGetsockopt,
+		},
 	}
 }
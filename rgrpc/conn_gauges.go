@@ -0,0 +1,79 @@
+package rgrpc
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// connGauges reports the latest TCP_INFO sample of every live tracked
+// connection as observable gauges labeled with conn_id, so one bad connection
+// stands out among several to the same remote_ip. Values come from the
+// sampler (periodic or slow-call); nothing is sampled at collection time.
+// Connections are only observed while they are in the registry, so their
+// series disappear once trackedConn closes.
+type connGauges struct {
+	m   *metrics
+	reg *connRegistry
+
+	rtt          metric.Float64ObservableGauge
+	minRTT       metric.Float64ObservableGauge
+	cwnd         metric.Float64ObservableGauge
+	totalRetrans metric.Float64ObservableGauge
+	unacked      metric.Float64ObservableGauge
+	deliveryRate metric.Float64ObservableGauge
+	rwndLimited  metric.Float64ObservableGauge
+	bytesAcked   metric.Float64ObservableGauge
+}
+
+// registerConnGauges creates the per-connection gauges and their callback.
+// The returned registration must be unregistered when the client closes.
+func registerConnGauges(m *metrics, reg *connRegistry) (metric.Registration, error) {
+	b := &instrumentBuilder{meter: m.meter, prefix: m.cfg.MetricPrefix}
+	g := &connGauges{
+		m:   m,
+		reg: reg,
+
+		rtt:          b.observableGauge("tcp_conn_rtt_ms", "ms", "Latest smoothed TCP RTT of the connection"),
+		minRTT:       b.observableGauge("tcp_conn_min_rtt_ms", "ms", "Lowest TCP RTT seen on the connection"),
+		cwnd:         b.observableGauge("tcp_conn_cwnd", "{segment}", "Latest TCP congestion window of the connection"),
+		totalRetrans: b.observableGauge("tcp_conn_total_retrans", "{segment}", "TCP segments retransmitted on the connection so far"),
+		unacked:      b.observableGauge("tcp_conn_unacked", "{segment}", "Latest TCP segments in flight on the connection"),
+		deliveryRate: b.observableGauge("tcp_conn_delivery_rate", "By/s", "Latest TCP delivery rate estimate of the connection"),
+		rwndLimited:  b.observableGauge("tcp_conn_rwnd_limited_ms", "ms", "Time the connection has been limited by the peer's receive window so far"),
+		bytesAcked:   b.observableGauge("tcp_conn_bytes_acked", "By", "Bytes acknowledged by the peer on the connection so far"),
+	}
+	if b.err != nil {
+		return nil, b.err
+	}
+	return m.meter.RegisterCallback(g.observe,
+		g.rtt, g.minRTT, g.cwnd, g.totalRetrans, g.unacked, g.deliveryRate, g.rwndLimited, g.bytesAcked)
+}
+
+func (g *connGauges) observe(_ context.Context, o metric.Observer) error {
+	for _, ci := range g.reg.snapshot() {
+		ci.mu.Lock()
+		s, sampled := ci.prev, ci.lastSampleUnix != 0
+		ci.mu.Unlock()
+		if !sampled || !s.Available {
+			continue
+		}
+
+		attrs := append([]attribute.KeyValue{
+			attribute.String("conn_id", ci.id),
+			attribute.String("remote_ip", ci.remoteIP),
+		}, g.m.cfg.Labels...)
+		opt := metric.WithAttributes(attrs...)
+
+		o.ObserveFloat64(g.rtt, durMs(s.RTT), opt)
+		o.ObserveFloat64(g.minRTT, durMs(s.MinRTT), opt)
+		o.ObserveFloat64(g.cwnd, float64(s.SndCwnd), opt)
+		o.ObserveFloat64(g.totalRetrans, float64(s.TotalRetrans), opt)
+		o.ObserveFloat64(g.unacked, float64(s.Unacked), opt)
+		o.ObserveFloat64(g.deliveryRate, float64(s.DeliveryRate), opt)
+		o.ObserveFloat64(g.rwndLimited, durMs(s.RwndLimited), opt)
+		o.ObserveFloat64(g.bytesAcked, float64(s.BytesAcked), opt)
+	}
+	return nil
+}
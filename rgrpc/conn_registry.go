@@ -4,29 +4,77 @@ import (
 	"context"
 	"net"
 	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
 )
 
 type connInfo struct {
 	local    string
 	remote   string
 	remoteIP string
+	id       string // local port + remote address, e.g. "54321->10.0.0.7:443"
+
+	createdUnix int64 // when the dialer returned the connection
 
 	tracker *tcpTracker // nil if unavailable
 
 	mu sync.Mutex
 
 	// sampling state
-	lastSampleUnix   int64
-	prevTotalRetrans uint32
+	lastSampleUnix int64
+	prev           TCPInfoSummary // previous sample, for deltas
+}
+
+// tcpDeltas are the growth of TCP_INFO's cumulative counters between samples.
+type tcpDeltas struct {
+	retrans       uint32
+	busy          time.Duration
+	rwndLimited   time.Duration
+	sndbufLimited time.Duration
+}
+
+// update records s as the connection's latest sample taken at now and returns
+// the deltas since the previous one. Caller holds ci.mu.
+func (ci *connInfo) update(s TCPInfoSummary, now time.Time) tcpDeltas {
+	ci.lastSampleUnix = now.UnixNano()
+	return ci.advance(s)
+}
+
+// advance returns the deltas from the previous sample to s and makes s the
+// previous sample. Caller holds ci.mu.
+func (ci *connInfo) advance(s TCPInfoSummary) tcpDeltas {
+	p := ci.prev
+	ci.prev = s
+
+	var d tcpDeltas
+	if s.TotalRetrans >= p.TotalRetrans {
+		d.retrans = s.TotalRetrans - p.TotalRetrans
+	}
+	if s.BusyTime >= p.BusyTime {
+		d.busy = s.BusyTime - p.BusyTime
+	}
+	if s.RwndLimited >= p.RwndLimited {
+		d.rwndLimited = s.RwndLimited - p.RwndLimited
+	}
+	if s.SndbufLimited >= p.SndbufLimited {
+		d.sndbufLimited = s.SndbufLimited - p.SndbufLimited
+	}
+	return d
 }
 
 type connRegistry struct {
 	mu sync.RWMutex
 	m  map[string]*connInfo
+
+	// onClosed receives the connection's final TCP_INFO snapshot, and its
+	// deltas since the last periodic sample, after it has been removed from
+	// the registry. May be nil.
+	onClosed func(ci *connInfo, final TCPInfoSummary, d tcpDeltas)
 }
 
-func newConnRegistry() *connRegistry {
-	return &connRegistry{m: make(map[string]*connInfo)}
+func newConnRegistry(onClosed func(ci *connInfo, final TCPInfoSummary, d tcpDeltas)) *connRegistry {
+	return &connRegistry{m: make(map[string]*connInfo), onClosed: onClosed}
 }
 
 func (r *connRegistry) key(local, remote string) string {
@@ -58,15 +106,22 @@ func (r *connRegistry) wrapConn(ctx context.Context, c net.Conn) net.Conn {
 		remoteIP = "unknown"
 	}
 
+	localPort := local
+	if _, p, err := net.SplitHostPort(local); err == nil {
+		localPort = p
+	}
+
 	ci := &connInfo{
-		local:    local,
-		remote:   remote,
-		remoteIP: remoteIP,
+		local:       local,
+		remote:      remote,
+		remoteIP:    remoteIP,
+		id:          localPort + "->" + remote,
+		createdUnix: unixNow(),
 	}
 
 	// TCP_INFO is supported only when the underlying conn is TCP and the platform supports it.
-	if tc, ok := c.(*net.TCPConn); ok {
-		if tr, err := newTCPTracker(tc); err == nil {
+	if sc := tcpSocket(c); sc != nil {
+		if tr, err := newTCPTracker(sc); err == nil {
 			ci.tracker = tr
 		}
 	}
@@ -75,26 +130,72 @@ func (r *connRegistry) wrapConn(ctx context.Context, c net.Conn) net.Conn {
 	r.m[r.key(local, remote)] = ci
 	r.mu.Unlock()
 
-	return &trackedConn{
-		Conn: c,
-		onClose: func() {
-			r.mu.Lock()
-			delete(r.m, r.key(local, remote))
-			r.mu.Unlock()
-			_ = ctx
-		},
+	_ = ctx
+	return &trackedConn{Conn: c, reg: r, ci: ci}
+}
+
+// closeConn takes the final TCP_INFO snapshot and closes c. The snapshot must
+// precede the close: afterwards the fd is gone. Short-lived connections are
+// often never reached by the periodic sampler, so this is their only sample.
+func (r *connRegistry) closeConn(c net.Conn, ci *connInfo) error {
+	var (
+		final TCPInfoSummary
+		d     tcpDeltas
+	)
+	if ci.tracker != nil {
+		ci.mu.Lock() // don't race the diag worker's sample
+		if s, ok := ci.tracker.Sample(); ok {
+			final, d = s, ci.advance(s)
+		}
+		ci.mu.Unlock()
+	}
+
+	err := c.Close()
+
+	r.mu.Lock()
+	delete(r.m, r.key(ci.local, ci.remote))
+	r.mu.Unlock()
+
+	if r.onClosed != nil {
+		r.onClosed(ci, final, d)
 	}
+	return err
+}
+
+// tcpSocket finds the TCP socket under c, looking through wrappers that expose
+// the wrapped connection via NetConn() (*tls.Conn and most proxy/middleware
+// conns follow this convention). Returns nil when c is not backed by TCP.
+func tcpSocket(c net.Conn) syscall.Conn {
+	for i := 0; c != nil && i < 16; i++ { // bound the walk in case of wrapper cycles
+		if tc, ok := c.(*net.TCPConn); ok {
+			return tc
+		}
+		if u, ok := c.(interface{ NetConn() net.Conn }); ok {
+			c = u.NetConn()
+			continue
+		}
+		break
+	}
+	// Unknown wrapper without NetConn: usable if it exposes the fd of a TCP socket.
+	if sc, ok := c.(syscall.Conn); ok {
+		if _, isTCP := c.LocalAddr().(*net.TCPAddr); isTCP {
+			return sc
+		}
+	}
+	return nil
 }
 
 type trackedConn struct {
 	net.Conn
-	onClose func()
+	reg *connRegistry
+	ci  *connInfo
+
+	closed atomic.Bool
 }
 
 func (c *trackedConn) Close() error {
-	err := c.Conn.Close()
-	if c.onClose != nil {
-		c.onClose()
+	if !c.closed.CompareAndSwap(false, true) {
+		return c.Conn.Close() // report the underlying "already closed" error
 	}
-	return err
+	return c.reg.closeConn(c.Conn, c.ci)
 }
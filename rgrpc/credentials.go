@@ -0,0 +1,46 @@
+package rgrpc
+
+import (
+	"context"
+	"net"
+	"time"
+
+	"google.golang.org/grpc/credentials"
+)
+
+// timedCredentials wraps TransportCredentials to time the client handshake.
+// The handshake runs on the trackedConn returned by hooks.dial, so the TCP
+// connect and the TLS handshake are measured as separate phases.
+type timedCredentials struct {
+	credentials.TransportCredentials
+	met *metrics
+}
+
+func newTimedCredentials(creds credentials.TransportCredentials, met *metrics) credentials.TransportCredentials {
+	return &timedCredentials{TransportCredentials: creds, met: met}
+}
+
+func (c *timedCredentials) ClientHandshake(ctx context.Context, authority string, rawConn net.Conn) (net.Conn, credentials.AuthInfo, error) {
+	start := time.Now()
+	conn, info, err := c.TransportCredentials.ClientHandshake(ctx, authority, rawConn)
+	elapsed := time.Since(start)
+
+	remoteIP := ipStringFromAddr(rawConn.RemoteAddr())
+	if remoteIP == "" {
+		remoteIP = "unknown"
+	}
+	if err != nil {
+		c.met.recordTLSFailure(ctx, remoteIP, handshakeFailureCause(ctx, err))
+		return nil, nil, err
+	}
+
+	// Plaintext "handshakes" are instantaneous; recording them would only add noise.
+	if tlsInfo, ok := info.(credentials.TLSInfo); ok {
+		c.met.recordTLSHandshake(ctx, remoteIP, &tlsInfo.State, elapsed)
+	}
+	return conn, info, nil
+}
+
+func (c *timedCredentials) Clone() credentials.TransportCredentials {
+	return newTimedCredentials(c.TransportCredentials.Clone(), c.met)
+}
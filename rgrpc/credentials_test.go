@@ -0,0 +1,141 @@
+package rgrpc
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"math/big"
+	"net"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// selfSignedTLS returns a server certificate for 127.0.0.1 and a pool trusting it.
+func selfSignedTLS(t *testing.T) (tls.Certificate, *x509.CertPool) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaf, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pool := x509.NewCertPool()
+	pool.AddCert(leaf)
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key, Leaf: leaf}, pool
+}
+
+// seriesAttrs returns the attribute sets of all data points of the named histogram, counter or gauge.
+func seriesAttrs(t *testing.T, r sdkmetric.Reader, name string) []attribute.Set {
+	t.Helper()
+	var rm metricdata.ResourceMetrics
+	if err := r.Collect(context.Background(), &rm); err != nil {
+		t.Fatal(err)
+	}
+	var out []attribute.Set
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			if m.Name != name {
+				continue
+			}
+			switch d := m.Data.(type) {
+			case metricdata.Histogram[float64]:
+				for _, dp := range d.DataPoints {
+					out = append(out, dp.Attributes)
+				}
+			case metricdata.Sum[int64]:
+				for _, dp := range d.DataPoints {
+					out = append(out, dp.Attributes)
+				}
+			case metricdata.Gauge[float64]:
+				for _, dp := range d.DataPoints {
+					out = append(out, dp.Attributes)
+				}
+			}
+		}
+	}
+	return out
+}
+
+// TestConnectAndHandshakePhases verifies that TCP connect and TLS handshake are
+// recorded as separate phases, and that connect failures are counted by cause.
+func TestConnectAndHandshakePhases(t *testing.T) {
+	cert, pool := selfSignedTLS(t)
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := grpc.NewServer(grpc.Creds(credentials.NewServerTLSFromCert(&cert)))
+	healthpb.RegisterHealthServer(srv, health.NewServer())
+	go srv.Serve(lis)
+	defer srv.Stop()
+
+	reader := sdkmetric.NewManualReader()
+	cc, err := New(context.Background(), "passthrough:///"+lis.Addr().String(),
+		WithTCPSampling(0),
+		WithMeterProvider(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))),
+		WithTransportCredentials(credentials.NewTLS(&tls.Config{RootCAs: pool})),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cc.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if _, err := healthpb.NewHealthClient(cc).Check(ctx, &healthpb.HealthCheckRequest{}); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := seriesAttrs(t, reader, "rgrpc.tcp_connect_ms"); len(got) != 1 {
+		t.Errorf("tcp_connect_ms: expected 1 series, got %v", got)
+	}
+	hs := seriesAttrs(t, reader, "rgrpc.tls_handshake_ms")
+	if len(hs) != 1 {
+		t.Fatalf("tls_handshake_ms: expected 1 series, got %v", hs)
+	}
+	if v, _ := hs[0].Value("tls_version"); v.AsString() != "TLS 1.3" {
+		t.Errorf("tls_version = %q, want TLS 1.3", v.AsString())
+	}
+	if v, _ := hs[0].Value("alpn"); v.AsString() != "h2" {
+		t.Errorf("alpn = %q, want h2", v.AsString())
+	}
+
+	// Connect failure against a closed port.
+	srv.Stop()
+	h := cc.hooks
+	if _, err := h.dial(context.Background(), lis.Addr().String()); err == nil {
+		t.Fatal("expected dial error")
+	}
+	failures := seriesAttrs(t, reader, "rgrpc.tcp_connect_failures")
+	if len(failures) != 1 {
+		t.Fatalf("tcp_connect_failures: expected 1 series, got %v", failures)
+	}
+	if v, _ := failures[0].Value("cause"); v.AsString() != "refused" {
+		t.Errorf("cause = %q, want refused", v.AsString())
+	}
+}
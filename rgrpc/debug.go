@@ -0,0 +1,489 @@
+package rgrpc
+
+import (
+	"encoding/json"
+	"html/template"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"google.golang.org/grpc/codes"
+)
+
+// debugCallHistory is how many slowest and recent failed calls each client
+// keeps for DebugHandler.
+const debugCallHistory = 32
+
+// debugSlowestWindow is how long a call stays eligible for the slowest list:
+// DebugHandler shows the slowest calls of the current and previous window.
+const debugSlowestWindow = 5 * time.Minute
+
+// debugClients holds every open client, for DebugHandler.
+var debugClients = struct {
+	mu sync.Mutex
+	m  map[*hooks]struct{}
+}{m: make(map[*hooks]struct{})}
+
+func registerDebugClient(h *hooks) {
+	debugClients.mu.Lock()
+	debugClients.m[h] = struct{}{}
+	debugClients.mu.Unlock()
+}
+
+func unregisterDebugClient(h *hooks) {
+	debugClients.mu.Lock()
+	delete(debugClients.m, h)
+	debugClients.mu.Unlock()
+}
+
+// callHistory keeps the n slowest calls of the current and previous window and
+// a ring of the n most recent failed calls. offer is on the call path: it only
+// takes the lock for failed calls, for calls slower than the current window's
+// n-th slowest, and once per window to rotate.
+type callHistory struct {
+	n      int
+	window int64 // ns
+
+	// floor is the total latency (ns) of the fastest of the current window's
+	// n slowest calls once the list is full, 0 before.
+	floor atomic.Int64
+	// windowEnd is when the current window ends (unix ns), 0 before the
+	// first call.
+	windowEnd atomic.Int64
+
+	mu          sync.Mutex
+	slowest     []CallRecord // current window
+	prevSlowest []CallRecord // previous window
+	failed      []CallRecord // ring; next is the oldest entry once full
+	next        int
+}
+
+func newCallHistory(n int, window time.Duration) *callHistory {
+	return &callHistory{n: n, window: int64(window)}
+}
+
+func (ch *callHistory) offer(target string, st *callState, code codes.Code, errClass string, callErr error, p callPhases) {
+	end := st.startUnix + int64(p.total)
+	failed := errClass != errorClassOK
+	rotate := end >= ch.windowEnd.Load()
+	slow := rotate || int64(p.total) > ch.floor.Load()
+	if !failed && !slow {
+		return
+	}
+	r := newCallRecord(target, st, code, errClass, callErr, p)
+
+	ch.mu.Lock()
+	defer ch.mu.Unlock()
+
+	if failed {
+		if len(ch.failed) < ch.n {
+			ch.failed = append(ch.failed, r)
+		} else {
+			ch.failed[ch.next] = r
+			ch.next = (ch.next + 1) % ch.n
+		}
+	}
+
+	if rotate {
+		ch.rotateLocked(end)
+	}
+	if slow {
+		if len(ch.slowest) < ch.n {
+			ch.slowest = append(ch.slowest, r)
+		} else if i := ch.fastestLocked(); r.Total > ch.slowest[i].Total {
+			ch.slowest[i] = r
+		}
+		if len(ch.slowest) == ch.n {
+			ch.floor.Store(int64(ch.slowest[ch.fastestLocked()].Total))
+		}
+	}
+}
+
+// rotateLocked starts a new window if now is past the current one. The current
+// window becomes the previous one, or both are dropped when more than a whole
+// window has gone by without a call.
+func (ch *callHistory) rotateLocked(now int64) {
+	windowEnd := ch.windowEnd.Load()
+	if now < windowEnd {
+		return
+	}
+	if now-windowEnd < ch.window {
+		ch.prevSlowest = ch.slowest
+	} else {
+		ch.prevSlowest = nil
+	}
+	ch.slowest = nil
+	ch.floor.Store(0)
+	ch.windowEnd.Store(now + ch.window)
+}
+
+func (ch *callHistory) fastestLocked() int {
+	min := 0
+	for i := range ch.slowest {
+		if ch.slowest[i].Total < ch.slowest[min].Total {
+			min = i
+		}
+	}
+	return min
+}
+
+// snapshot returns the n slowest calls of the current and previous window as of
+// now (unix ns), slowest first, and the failed calls, most recent first.
+func (ch *callHistory) snapshot(now int64) (slowest, failed []CallRecord) {
+	ch.mu.Lock()
+	ch.rotateLocked(now)
+	slowest = append(append([]CallRecord(nil), ch.slowest...), ch.prevSlowest...)
+	for i := 0; i < len(ch.failed); i++ {
+		// Walk backwards from the newest entry.
+		j := (ch.next - 1 - i + 2*len(ch.failed)) % len(ch.failed)
+		failed = append(failed, ch.failed[j])
+	}
+	ch.mu.Unlock()
+
+	sort.Slice(slowest, func(i, j int) bool { return slowest[i].Total > slowest[j].Total })
+	if len(slowest) > ch.n {
+		slowest = slowest[:ch.n]
+	}
+	return slowest, failed
+}
+
+// DebugHandler returns an http.Handler that shows every open rgrpc client in
+// the process: target, configuration, live connections with their latest
+// TCP_INFO sample, per-method rolling latency percentiles and, for clients
+// with Config.EnableCallHistory, the slowest calls of the last 5 to 10 minutes
+// and the most recent failed calls with their phase breakdown.
+//
+// It renders HTML, or JSON with ?format=json or an Accept: application/json
+// header. Mount it on an internal-only listener, like net/http/pprof:
+//
+//	http.Handle("/debug/rgrpc", rgrpc.DebugHandler())
+func DebugHandler() http.Handler {
+	return debugHandler{}
+}
+
+type debugHandler struct{}
+
+func (debugHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	views := debugSnapshot()
+
+	if r.URL.Query().Get("format") == "json" || strings.Contains(r.Header.Get("Accept"), "application/json") {
+		w.Header().Set("Content-Type", "application/json")
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		_ = enc.Encode(views)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := debugTemplate.Execute(w, views); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+type debugClientView struct {
+	Target       string            `json:"target"`
+	Config       debugConfigView   `json:"config"`
+	Connections  []debugConnView   `json:"connections"`
+	Methods      []debugMethodView `json:"methods"`
+	SlowestCalls []debugCallView   `json:"slowest_calls"`
+	FailedCalls  []debugCallView   `json:"recent_failed_calls"`
+}
+
+type debugConfigView struct {
+	MetricPrefix            string            `json:"metric_prefix"`
+	ClientSideLB            bool              `json:"client_side_lb"`
+	LoadBalancingPolicy     string            `json:"load_balancing_policy,omitempty"`
+	Labels                  map[string]string `json:"labels,omitempty"`
+	BlockingWarmup          bool              `json:"blocking_warmup"`
+	Tracing                 bool              `json:"tracing"`
+	ResolverMetrics         bool              `json:"resolver_metrics"`
+	CustomDialer            bool              `json:"custom_dialer"`
+	TransportCredentials    string            `json:"transport_credentials,omitempty"`
+	CustomResolver          string            `json:"custom_resolver,omitempty"`
+	TCPMetricsInterval      string            `json:"tcp_metrics_interval"`
+	TCPSampler              TCPSamplerConfig  `json:"tcp_sampler"`
+	PerConnectionTCPMetrics bool              `json:"per_connection_tcp_metrics"`
+	SlowCallThreshold       string            `json:"slow_call_threshold,omitempty"`
+	SlowCallPercentile      float64           `json:"slow_call_percentile,omitempty"`
+	RetryMaxAttempts        int               `json:"retry_max_attempts,omitempty"`
+	HedgedMethods           []string          `json:"hedged_methods,omitempty"`
+	CircuitBreaker          bool              `json:"circuit_breaker"`
+	OutlierDetection        bool              `json:"outlier_detection"`
+	CallHistory             bool              `json:"call_history"`
+}
+
+type debugConnView struct {
+	ID         string        `json:"id"`
+	Local      string        `json:"local"`
+	Remote     string        `json:"remote"`
+	RemoteIP   string        `json:"remote_ip"`
+	AgeS       float64       `json:"age_s"`
+	LastSample *time.Time    `json:"last_sample,omitempty"`
+	TCP        *debugTCPView `json:"tcp,omitempty"`
+}
+
+type debugTCPView struct {
+	RTTMs           float64 `json:"rtt_ms"`
+	RTTVarMs        float64 `json:"rttvar_ms"`
+	MinRTTMs        float64 `json:"min_rtt_ms"`
+	SndCwnd         uint32  `json:"snd_cwnd"`
+	SndSsthresh     uint32  `json:"snd_ssthresh"`
+	TotalRetrans    uint32  `json:"total_retrans"`
+	Unacked         uint32  `json:"unacked"`
+	Lost            uint32  `json:"lost"`
+	Retrans         uint32  `json:"retrans"`
+	Reordering      uint32  `json:"reordering"`
+	RcvSpace        uint32  `json:"rcv_space"`
+	NotsentBytes    uint32  `json:"notsent_bytes"`
+	DeliveryRate    uint64  `json:"delivery_rate"`
+	PacingRate      uint64  `json:"pacing_rate"`
+	BusyMs          float64 `json:"busy_ms"`
+	RwndLimitedMs   float64 `json:"rwnd_limited_ms"`
+	SndbufLimitedMs float64 `json:"sndbuf_limited_ms"`
+	BytesSent       uint64  `json:"bytes_sent"`
+	BytesAcked      uint64  `json:"bytes_acked"`
+}
+
+type debugMethodView struct {
+	Method  string  `json:"method"`
+	Samples uint64  `json:"samples"`
+	P50Ms   float64 `json:"p50_ms"`
+	P90Ms   float64 `json:"p90_ms"`
+	P99Ms   float64 `json:"p99_ms"`
+}
+
+type debugCallView struct {
+	Method            string    `json:"method"`
+	Start             time.Time `json:"start"`
+	RemoteIP          string    `json:"remote_ip"`
+	Streaming         bool      `json:"streaming"`
+	GRPCCode          string    `json:"grpc_code"`
+	ErrorClass        string    `json:"error_class"`
+	Error             string    `json:"error,omitempty"`
+	TraceID           string    `json:"trace_id,omitempty"`
+	Attempts          uint32    `json:"attempts"`
+	TotalMs           float64   `json:"total_ms"`
+	PickWaitMs        float64   `json:"pick_wait_ms"`
+	StreamEstablishMs float64   `json:"stream_establish_ms"`
+	SendStallMs       float64   `json:"send_stall_ms"`
+	ResponseWaitMs    float64   `json:"response_wait_ms"`
+	BytesSent         int64     `json:"bytes_sent"`
+	BytesReceived     int64     `json:"bytes_received"`
+}
+
+// debugSnapshot builds the views of all open clients, sorted by target.
+func debugSnapshot() []debugClientView {
+	debugClients.mu.Lock()
+	hs := make([]*hooks, 0, len(debugClients.m))
+	for h := range debugClients.m {
+		hs = append(hs, h)
+	}
+	debugClients.mu.Unlock()
+
+	views := make([]debugClientView, 0, len(hs))
+	for _, h := range hs {
+		views = append(views, h.debugView())
+	}
+	sort.SliceStable(views, func(i, j int) bool { return views[i].Target < views[j].Target })
+	return views
+}
+
+func (h *hooks) debugView() debugClientView {
+	v := debugClientView{
+		Target:      h.target,
+		Config:      newDebugConfigView(h.cfg),
+		Connections: []debugConnView{},
+		Methods:     []debugMethodView{},
+	}
+
+	now := time.Now()
+	for _, ci := range h.reg.snapshot() {
+		cv := debugConnView{
+			ID:       ci.id,
+			Local:    ci.local,
+			Remote:   ci.remote,
+			RemoteIP: ci.remoteIP,
+			AgeS:     now.Sub(time.Unix(0, ci.createdUnix)).Seconds(),
+		}
+		ci.mu.Lock()
+		s, last := ci.prev, ci.lastSampleUnix
+		ci.mu.Unlock()
+		if last != 0 && s.Available {
+			t := time.Unix(0, last)
+			cv.LastSample = &t
+			cv.TCP = newDebugTCPView(s)
+		}
+		v.Connections = append(v.Connections, cv)
+	}
+	sort.Slice(v.Connections, func(i, j int) bool { return v.Connections[i].ID < v.Connections[j].ID })
+
+	for method, hist := range h.latencies.snapshot() {
+		mv := debugMethodView{Method: method, Samples: hist.count()}
+		mv.P50Ms, _ = hist.quantile(0.5, 1)
+		mv.P90Ms, _ = hist.quantile(0.9, 1)
+		mv.P99Ms, _ = hist.quantile(0.99, 1)
+		v.Methods = append(v.Methods, mv)
+	}
+	sort.Slice(v.Methods, func(i, j int) bool { return v.Methods[i].Method < v.Methods[j].Method })
+
+	var slowest, failed []CallRecord
+	if h.history != nil {
+		slowest, failed = h.history.snapshot(now.UnixNano())
+	}
+	v.SlowestCalls = newDebugCallViews(slowest)
+	v.FailedCalls = newDebugCallViews(failed)
+	return v
+}
+
+func newDebugConfigView(cfg Config) debugConfigView {
+	v := debugConfigView{
+		MetricPrefix:            cfg.MetricPrefix,
+		ClientSideLB:            cfg.EnableClientSideLB,
+		LoadBalancingPolicy:     cfg.LoadBalancingPolicy,
+		BlockingWarmup:          cfg.BlockingWarmup,
+		Tracing:                 cfg.EnableTracing,
+		ResolverMetrics:         cfg.EnableResolverMetrics,
+		CustomDialer:            cfg.Dialer != nil,
+		TCPMetricsInterval:      cfg.TCPMetricsInterval.String(),
+		TCPSampler:              cfg.TCPSampler.withDefaults(),
+		PerConnectionTCPMetrics: cfg.PerConnectionTCPMetrics,
+		SlowCallPercentile:      cfg.SlowCall.Percentile,
+		RetryMaxAttempts:        cfg.Retry.Policy.MaxAttempts,
+		HedgedMethods:           cfg.Hedging.Methods,
+		CircuitBreaker:          cfg.CircuitBreaker.Enabled,
+		OutlierDetection:        cfg.OutlierDetection.Enabled,
+		CallHistory:             cfg.EnableCallHistory,
+	}
+	if len(cfg.Labels) > 0 {
+		v.Labels = make(map[string]string, len(cfg.Labels))
+		for _, kv := range cfg.Labels {
+			v.Labels[string(kv.Key)] = kv.Value.Emit()
+		}
+	}
+	if cfg.TransportCredentials != nil {
+		v.TransportCredentials = cfg.TransportCredentials.Info().SecurityProtocol
+	}
+	if cfg.Resolver != nil {
+		v.CustomResolver = cfg.Resolver.Scheme()
+	}
+	if cfg.SlowCall.Threshold > 0 {
+		v.SlowCallThreshold = cfg.SlowCall.Threshold.String()
+	}
+	return v
+}
+
+func newDebugTCPView(s TCPInfoSummary) *debugTCPView {
+	return &debugTCPView{
+		RTTMs:           durMs(s.RTT),
+		RTTVarMs:        durMs(s.RTTVar),
+		MinRTTMs:        durMs(s.MinRTT),
+		SndCwnd:         s.SndCwnd,
+		SndSsthresh:     s.SndSsthresh,
+		TotalRetrans:    s.TotalRetrans,
+		Unacked:         s.Unacked,
+		Lost:            s.Lost,
+		Retrans:         s.Retrans,
+		Reordering:      s.Reordering,
+		RcvSpace:        s.RcvSpace,
+		NotsentBytes:    s.NotsentBytes,
+		DeliveryRate:    s.DeliveryRate,
+		PacingRate:      s.PacingRate,
+		BusyMs:          durMs(s.BusyTime),
+		RwndLimitedMs:   durMs(s.RwndLimited),
+		SndbufLimitedMs: durMs(s.SndbufLimited),
+		BytesSent:       s.BytesSent,
+		BytesAcked:      s.BytesAcked,
+	}
+}
+
+func newDebugCallViews(rs []CallRecord) []debugCallView {
+	out := make([]debugCallView, 0, len(rs))
+	for _, r := range rs {
+		v := debugCallView{
+			Method:            r.Method,
+			Start:             r.Start,
+			RemoteIP:          r.RemoteIP,
+			Streaming:         r.Streaming,
+			GRPCCode:          grpcCodeName(r.Code),
+			ErrorClass:        r.ErrorClass,
+			TraceID:           r.TraceID,
+			Attempts:          uint32(r.Attempts),
+			TotalMs:           durMs(r.Total),
+			PickWaitMs:        durMs(r.PickWait),
+			StreamEstablishMs: durMs(r.StreamEstablish),
+			SendStallMs:       durMs(r.SendStall),
+			ResponseWaitMs:    durMs(r.ResponseWait),
+			BytesSent:         r.BytesSent,
+			BytesReceived:     r.BytesReceived,
+		}
+		if r.Err != nil {
+			v.Error = r.Err.Error()
+		}
+		out = append(out, v)
+	}
+	return out
+}
+
+var debugTemplate = template.Must(template.New("rgrpc").Parse(`<!DOCTYPE html>
+<html><head><meta charset="utf-8"><title>rgrpc clients</title>
+<style>
+body { font-family: sans-serif; font-size: 13px; }
+table { border-collapse: collapse; margin-bottom: 1em; }
+th, td { border: 1px solid #ccc; padding: 2px 6px; text-align: left; }
+th { background: #eee; }
+td.num { text-align: right; }
+</style></head><body>
+<h1>rgrpc clients ({{len .}})</h1>
+<p><a href="?format=json">JSON</a></p>
+{{range .}}
+<h2>{{.Target}}</h2>
+<details><summary>Config</summary>
+<table>
+<tr><th>metric_prefix</th><td>{{.Config.MetricPrefix}}</td></tr>
+<tr><th>client_side_lb</th><td>{{.Config.ClientSideLB}} {{.Config.LoadBalancingPolicy}}</td></tr>
+<tr><th>labels</th><td>{{range $k, $v := .Config.Labels}}{{$k}}={{$v}} {{end}}</td></tr>
+<tr><th>blocking_warmup</th><td>{{.Config.BlockingWarmup}}</td></tr>
+<tr><th>tracing</th><td>{{.Config.Tracing}}</td></tr>
+<tr><th>resolver_metrics</th><td>{{.Config.ResolverMetrics}}</td></tr>
+<tr><th>custom_dialer</th><td>{{.Config.CustomDialer}}</td></tr>
+<tr><th>transport_credentials</th><td>{{.Config.TransportCredentials}}</td></tr>
+<tr><th>custom_resolver</th><td>{{.Config.CustomResolver}}</td></tr>
+<tr><th>tcp_metrics_interval</th><td>{{.Config.TCPMetricsInterval}}</td></tr>
+<tr><th>tcp_sampler</th><td>{{.Config.TCPSampler.Backend}}, {{.Config.TCPSampler.RatePerSec}}/s, burst {{.Config.TCPSampler.Burst}}, cooldown {{.Config.TCPSampler.Cooldown}}, queue {{.Config.TCPSampler.QueueSize}}</td></tr>
+<tr><th>per_connection_tcp_metrics</th><td>{{.Config.PerConnectionTCPMetrics}}</td></tr>
+<tr><th>slow_call</th><td>threshold {{.Config.SlowCallThreshold}}, percentile {{.Config.SlowCallPercentile}}</td></tr>
+<tr><th>retry_max_attempts</th><td>{{.Config.RetryMaxAttempts}}</td></tr>
+<tr><th>hedged_methods</th><td>{{range .Config.HedgedMethods}}{{.}} {{end}}</td></tr>
+<tr><th>circuit_breaker</th><td>{{.Config.CircuitBreaker}}</td></tr>
+<tr><th>outlier_detection</th><td>{{.Config.OutlierDetection}}</td></tr>
+<tr><th>call_history</th><td>{{.Config.CallHistory}}</td></tr>
+</table></details>
+
+<h3>Connections ({{len .Connections}})</h3>
+<table>
+<tr><th>id</th><th>remote_ip</th><th>age s</th><th>last sample</th><th>rtt ms</th><th>min rtt ms</th><th>cwnd</th><th>total retrans</th><th>unacked</th><th>lost</th><th>delivery B/s</th><th>busy ms</th><th>rwnd limited ms</th><th>sndbuf limited ms</th><th>notsent B</th><th>bytes acked</th></tr>
+{{range .Connections}}<tr><td>{{.ID}}</td><td>{{.RemoteIP}}</td><td class="num">{{printf "%.0f" .AgeS}}</td>
+{{- if .TCP}}<td>{{.LastSample.Format "15:04:05"}}</td>{{with .TCP}}<td class="num">{{printf "%.3f" .RTTMs}}</td><td class="num">{{printf "%.3f" .MinRTTMs}}</td><td class="num">{{.SndCwnd}}</td><td class="num">{{.TotalRetrans}}</td><td class="num">{{.Unacked}}</td><td class="num">{{.Lost}}</td><td class="num">{{.DeliveryRate}}</td><td class="num">{{printf "%.0f" .BusyMs}}</td><td class="num">{{printf "%.0f" .RwndLimitedMs}}</td><td class="num">{{printf "%.0f" .SndbufLimitedMs}}</td><td class="num">{{.NotsentBytes}}</td><td class="num">{{.BytesAcked}}</td>{{end}}
+{{- else}}<td colspan="13">not sampled yet</td>{{end}}</tr>
+{{end}}</table>
+
+<h3>Methods</h3>
+<table>
+<tr><th>method</th><th>samples</th><th>p50 ms</th><th>p90 ms</th><th>p99 ms</th></tr>
+{{range .Methods}}<tr><td>{{.Method}}</td><td class="num">{{.Samples}}</td><td class="num">{{printf "%.3f" .P50Ms}}</td><td class="num">{{printf "%.3f" .P90Ms}}</td><td class="num">{{printf "%.3f" .P99Ms}}</td></tr>
+{{end}}</table>
+
+<h3>Slowest calls</h3>
+{{template "calls" .SlowestCalls}}
+<h3>Recent failed calls</h3>
+{{template "calls" .FailedCalls}}
+{{end}}
+</body></html>
+{{define "calls"}}<table>
+<tr><th>start</th><th>method</th><th>remote_ip</th><th>grpc_code</th><th>error_class</th><th>attempts</th><th>total ms</th><th>pick wait ms</th><th>stream establish ms</th><th>send stall ms</th><th>response wait ms</th><th>trace</th><th>error</th></tr>
+{{range .}}<tr><td>{{.Start.Format "2006-01-02 15:04:05.000"}}</td><td>{{.Method}}{{if .Streaming}} (stream){{end}}</td><td>{{.RemoteIP}}</td><td>{{.GRPCCode}}</td><td>{{.ErrorClass}}</td><td class="num">{{.Attempts}}</td><td class="num">{{printf "%.3f" .TotalMs}}</td><td class="num">{{printf "%.3f" .PickWaitMs}}</td><td class="num">{{printf "%.3f" .StreamEstablishMs}}</td><td class="num">{{printf "%.3f" .SendStallMs}}</td><td class="num">{{printf "%.3f" .ResponseWaitMs}}</td><td>{{.TraceID}}</td><td>{{.Error}}</td></tr>
+{{end}}</table>{{end}}
+`))
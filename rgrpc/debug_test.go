@@ -0,0 +1,205 @@
+package rgrpc
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// TestDebugHandler verifies that an open client shows up in both renderings
+// with its connection, per-method latencies and call history, and disappears
+// once closed.
+func TestDebugHandler(t *testing.T) {
+	addr := startHealthServer(t, nil)
+	target := "passthrough:///" + addr
+	cc, err := New(context.Background(), target,
+		WithTCPSampling(0),
+		WithCallHistory(true),
+		WithDialOptions(grpc.WithTransportCredentials(insecure.NewCredentials())),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	client := healthpb.NewHealthClient(cc)
+	if _, err := client.Check(ctx, &healthpb.HealthCheckRequest{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.Check(ctx, &healthpb.HealthCheckRequest{Service: "unknown"}); err == nil {
+		t.Fatal("expected NotFound for an unknown service")
+	}
+
+	srv := httptest.NewServer(DebugHandler())
+	defer srv.Close()
+
+	find := func() *debugClientView {
+		resp, err := http.Get(srv.URL + "?format=json")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		var views []debugClientView
+		if err := json.NewDecoder(resp.Body).Decode(&views); err != nil {
+			t.Fatal(err)
+		}
+		for i := range views {
+			if views[i].Target == target {
+				return &views[i]
+			}
+		}
+		return nil
+	}
+
+	v := find()
+	if v == nil {
+		t.Fatalf("client %s not listed", target)
+	}
+	if len(v.Connections) != 1 || v.Connections[0].Remote != addr {
+		t.Errorf("connections = %+v, want one to %s", v.Connections, addr)
+	}
+	if len(v.Methods) != 1 || v.Methods[0].Method != "/grpc.health.v1.Health/Check" || v.Methods[0].Samples != 2 {
+		t.Errorf("methods = %+v", v.Methods)
+	}
+	if len(v.SlowestCalls) != 2 {
+		t.Errorf("slowest_calls: got %d, want 2", len(v.SlowestCalls))
+	}
+	if len(v.FailedCalls) != 1 || v.FailedCalls[0].GRPCCode != "NOT_FOUND" {
+		t.Errorf("recent_failed_calls = %+v", v.FailedCalls)
+	}
+
+	resp, err := http.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || !strings.Contains(string(body), addr) {
+		t.Errorf("HTML page: status %d, target missing:\n%s", resp.StatusCode, body)
+	}
+
+	cc.Close()
+	if find() != nil {
+		t.Error("closed client still listed")
+	}
+}
+
+// TestDebugHandlerWithoutCallHistory verifies that a client without
+// EnableCallHistory is listed but records no calls.
+func TestDebugHandlerWithoutCallHistory(t *testing.T) {
+	addr := startHealthServer(t, nil)
+	target := "passthrough:///" + addr
+	cc, err := New(context.Background(), target,
+		WithTCPSampling(0),
+		WithDialOptions(grpc.WithTransportCredentials(insecure.NewCredentials())),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cc.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	client := healthpb.NewHealthClient(cc)
+	if _, err := client.Check(ctx, &healthpb.HealthCheckRequest{Service: "unknown"}); err == nil {
+		t.Fatal("expected NotFound for an unknown service")
+	}
+
+	var v *debugClientView
+	for _, cv := range debugSnapshot() {
+		if cv.Target == target {
+			v = &cv
+		}
+	}
+	if v == nil {
+		t.Fatalf("client %s not listed", target)
+	}
+	if len(v.Methods) != 1 {
+		t.Errorf("methods = %+v", v.Methods)
+	}
+	if len(v.SlowestCalls) != 0 || len(v.FailedCalls) != 0 {
+		t.Errorf("call history recorded while disabled: slowest %+v, failed %+v", v.SlowestCalls, v.FailedCalls)
+	}
+}
+
+// TestCallHistory verifies that callHistory keeps only the n slowest calls and
+// the n most recent failures, newest first.
+func TestCallHistory(t *testing.T) {
+	now := time.Now().UnixNano()
+	ch := newCallHistory(3, time.Minute)
+	for i := 1; i <= 10; i++ {
+		st := &callState{method: "/svc/M", startUnix: now}
+		code, class := codes.OK, errorClassOK
+		if i%2 == 0 {
+			code, class = codes.Unavailable, errorClassTransport
+		}
+		ch.offer("", st, code, class, nil, callPhases{total: time.Duration(i) * time.Millisecond, attempts: uint32(i)})
+	}
+
+	slowest, failed := ch.snapshot(now)
+	var got []uint32
+	for _, r := range slowest {
+		got = append(got, uint32(r.Attempts))
+	}
+	if len(got) != 3 || got[0] != 10 || got[1] != 9 || got[2] != 8 {
+		t.Errorf("slowest = %v, want [10 9 8]", got)
+	}
+	got = got[:0]
+	for _, r := range failed {
+		got = append(got, uint32(r.Attempts))
+	}
+	if len(got) != 3 || got[0] != 10 || got[1] != 8 || got[2] != 6 {
+		t.Errorf("failed = %v, want [10 8 6]", got)
+	}
+}
+
+// TestCallHistoryWindow verifies that slow calls age out of the slowest list
+// after one to two windows, while recent failures stay.
+func TestCallHistoryWindow(t *testing.T) {
+	const window = time.Minute
+	base := time.Now().UnixNano()
+	ch := newCallHistory(2, window)
+	offer := func(at int64, total time.Duration, attempts uint32) {
+		st := &callState{method: "/svc/M", startUnix: at}
+		ch.offer("", st, codes.OK, errorClassOK, nil, callPhases{total: total, attempts: attempts})
+	}
+	attempts := func(rs []CallRecord) []uint32 {
+		var got []uint32
+		for _, r := range rs {
+			got = append(got, uint32(r.Attempts))
+		}
+		return got
+	}
+
+	offer(base, time.Second, 1)
+	offer(base, 2*time.Second, 2)
+	// Faster calls in the next window are listed after the previous window's.
+	next := base + int64(window) + int64(time.Second)
+	offer(next, time.Millisecond, 3)
+	offer(next, 2*time.Millisecond, 4)
+	if got := attempts(slowestOf(ch.snapshot(next))); len(got) != 2 || got[0] != 2 || got[1] != 1 {
+		t.Errorf("slowest after one window = %v, want [2 1]", got)
+	}
+	// One window later the first window has aged out.
+	later := next + int64(window) + int64(time.Second)
+	if got := attempts(slowestOf(ch.snapshot(later))); len(got) != 2 || got[0] != 4 || got[1] != 3 {
+		t.Errorf("slowest after two windows = %v, want [4 3]", got)
+	}
+	// After a whole idle window nothing is left.
+	if got := slowestOf(ch.snapshot(later + 2*int64(window))); len(got) != 0 {
+		t.Errorf("slowest after idle windows = %v, want none", attempts(got))
+	}
+}
+
+func slowestOf(slowest, _ []CallRecord) []CallRecord { return slowest }
@@ -7,16 +7,32 @@ import (
 	"golang.org/x/time/rate"
 )
 
+// Values of the trigger attribute on TCP_INFO metrics: what caused the sample.
+const (
+	triggerPeriodic = "periodic"
+	triggerSlowCall = "slow_call"
+	triggerClose    = "close"
+)
+
 type diagRequest struct {
 	local    string
 	remote   string
 	remoteIP string
+	trigger  string
 }
 
+// Reasons a sample request is dropped (reason attribute of tcp_samples_dropped).
+const (
+	dropQueueFull   = "queue_full"
+	dropRateLimited = "rate_limited"
+	dropCooldown    = "cooldown"
+)
+
 type diagWorker struct {
-	cfg Config
-	reg *connRegistry
-	met *metrics
+	cfg      Config
+	reg      *connRegistry
+	met      *metrics
+	cooldown time.Duration
 
 	ch  chan diagRequest
 	lim *rate.Limiter
@@ -24,22 +40,22 @@ type diagWorker struct {
 	stopCh chan struct{}
 }
 
-const (
-	tcpInfoRatePerSec = 4.0
-	tcpInfoBurst      = 8
-	tcpInfoCooldown   = 10 * time.Second
-)
-
 func newDiagWorker(cfg Config, reg *connRegistry, met *metrics, parentStop <-chan struct{}) *diagWorker {
+	sc := cfg.TCPSampler.withDefaults()
 	w := &diagWorker{
-		cfg:    cfg,
-		reg:    reg,
-		met:    met,
-		ch:     make(chan diagRequest, 512),
-		stopCh: make(chan struct{}),
+		cfg:      cfg,
+		reg:      reg,
+		met:      met,
+		cooldown: sc.Cooldown,
+		ch:       make(chan diagRequest, sc.QueueSize),
+		stopCh:   make(chan struct{}),
 	}
 
-	w.lim = rate.NewLimiter(rate.Limit(tcpInfoRatePerSec), tcpInfoBurst)
+	limit := rate.Limit(sc.RatePerSec)
+	if sc.RatePerSec < 0 {
+		limit = rate.Inf
+	}
+	w.lim = rate.NewLimiter(limit, sc.Burst)
 
 	go func() {
 		select {
@@ -65,14 +81,54 @@ func (w *diagWorker) enqueuePeriodic(ci *connInfo) {
 	if ci == nil || ci.tracker == nil {
 		return
 	}
-	req := diagRequest{
+	w.enqueue(diagRequest{
 		local:    ci.local,
 		remote:   ci.remote,
 		remoteIP: ci.remoteIP,
+		trigger:  triggerPeriodic,
+	})
+}
+
+// sampleAll samples conns with one sock_diag dump instead of a getsockopt per
+// connection, so neither the rate limit nor the cooldown applies. Connections
+// missing from the dump, or all of them if the dump fails (non-Linux, seccomp,
+// another network namespace), fall back to enqueuePeriodic.
+func (w *diagWorker) sampleAll(conns []*connInfo) {
+	infos, _ := netlinkTCPInfo(conns)
+	now := time.Now()
+	for _, ci := range conns {
+		summary, ok := infos[w.reg.key(ci.local, ci.remote)]
+		if !ok {
+			w.enqueuePeriodic(ci)
+			continue
+		}
+		ci.mu.Lock()
+		d := ci.update(summary, now)
+		ci.mu.Unlock()
+
+		w.met.recordTCP(context.Background(), ci.remoteIP, triggerPeriodic, summary, d)
+		w.met.recordTCPSample(context.Background(), triggerPeriodic)
 	}
+}
+
+// enqueueSlowCall requests a sample of the connection a slow call ran on.
+// It goes through the same rate limit and per-connection cooldown as periodic
+// samples, so a burst of slow calls costs at most one syscall per connection.
+func (w *diagWorker) enqueueSlowCall(local, remote, remoteIP string) {
+	w.enqueue(diagRequest{
+		local:    local,
+		remote:   remote,
+		remoteIP: remoteIP,
+		trigger:  triggerSlowCall,
+	})
+}
+
+// enqueue never blocks: when the queue is full the request is dropped.
+func (w *diagWorker) enqueue(req diagRequest) {
 	select {
 	case w.ch <- req:
 	default:
+		w.met.recordTCPSampleDropped(context.Background(), req.trigger, dropQueueFull)
 	}
 }
 
@@ -84,6 +140,7 @@ func (w *diagWorker) loop() {
 		case req := <-w.ch:
 			// never block: if we can't sample now, skip
 			if !w.lim.Allow() {
+				w.met.recordTCPSampleDropped(context.Background(), req.trigger, dropRateLimited)
 				continue
 			}
 
@@ -96,10 +153,11 @@ func (w *diagWorker) loop() {
 
 			ci.mu.Lock()
 			// cooldown
-			if ci.lastSampleUnix != 0 {
+			if w.cooldown > 0 && ci.lastSampleUnix != 0 {
 				last := time.Unix(0, ci.lastSampleUnix)
-				if now.Sub(last) < tcpInfoCooldown {
+				if now.Sub(last) < w.cooldown {
 					ci.mu.Unlock()
+					w.met.recordTCPSampleDropped(context.Background(), req.trigger, dropCooldown)
 					continue
 				}
 			}
@@ -110,16 +168,12 @@ func (w *diagWorker) loop() {
 				continue
 			}
 
-			var retransDelta uint32
-			if summary.TotalRetrans >= ci.prevTotalRetrans {
-				retransDelta = summary.TotalRetrans - ci.prevTotalRetrans
-			}
-			ci.prevTotalRetrans = summary.TotalRetrans
-			ci.lastSampleUnix = now.UnixNano()
+			d := ci.update(summary, now)
 			ci.mu.Unlock()
 
-			// Record TCP metrics (same bounded label scheme as call histograms: remote_ip and optional method).
-			w.met.recordTCP(context.Background(), req.remoteIP, summary, retransDelta)
+			// Record TCP metrics (bounded labels: remote_ip and the trigger).
+			w.met.recordTCP(context.Background(), req.remoteIP, req.trigger, summary, d)
+			w.met.recordTCPSample(context.Background(), req.trigger)
 		}
 	}
 }
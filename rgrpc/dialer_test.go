@@ -0,0 +1,89 @@
+package rgrpc
+
+import (
+	"context"
+	"net"
+	"runtime"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// wrappedConn mimics a middleware conn (proxy, TLS) that exposes the wrapped conn.
+type wrappedConn struct {
+	net.Conn
+}
+
+func (c *wrappedConn) NetConn() net.Conn { return c.Conn }
+
+// TestUserDialerIsTracked verifies that a user dialer is used and that its
+// connection is still tracked, with the TCP socket found through wrappers.
+func TestUserDialerIsTracked(t *testing.T) {
+	addr := startHealthServer(t, nil)
+
+	var dials atomic.Int32
+	dial := func(ctx context.Context, addr string) (net.Conn, error) {
+		dials.Add(1)
+		var d net.Dialer
+		c, err := d.DialContext(ctx, "tcp", addr)
+		if err != nil {
+			return nil, err
+		}
+		return &wrappedConn{Conn: c}, nil
+	}
+
+	cc, err := New(context.Background(), "passthrough:///"+addr,
+		WithTCPSampling(0),
+		WithDialer(dial),
+		WithDialOptions(grpc.WithTransportCredentials(insecure.NewCredentials())),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cc.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if _, err := healthpb.NewHealthClient(cc).Check(ctx, &healthpb.HealthCheckRequest{}); err != nil {
+		t.Fatal(err)
+	}
+
+	if dials.Load() == 0 {
+		t.Fatal("user dialer was not called")
+	}
+	conns := cc.hooks.reg.snapshot()
+	if len(conns) != 1 {
+		t.Fatalf("expected 1 tracked conn, got %d", len(conns))
+	}
+	if runtime.GOOS == "linux" && conns[0].tracker == nil {
+		t.Error("TCP socket was not found through the wrapper")
+	}
+}
+
+// TestContextDialerOptionRejected verifies that a grpc.WithContextDialer dial
+// option, which would bypass connection tracking, is rejected, and that other
+// dial options are not mistaken for it.
+func TestContextDialerOptionRejected(t *testing.T) {
+	dial := func(ctx context.Context, addr string) (net.Conn, error) {
+		var d net.Dialer
+		return d.DialContext(ctx, "tcp", addr)
+	}
+	_, err := New(context.Background(), "passthrough:///localhost:1",
+		WithTCPSampling(0),
+		WithDialOptions(grpc.WithTransportCredentials(insecure.NewCredentials()), grpc.WithContextDialer(dial)),
+	)
+	if err == nil || !strings.Contains(err.Error(), "WithDialer") {
+		t.Fatalf("New with grpc.WithContextDialer: err = %v, want an error pointing to WithDialer", err)
+	}
+
+	for _, opt := range []grpc.DialOption{grpc.WithUserAgent("x"), grpc.WithAuthority("x"), grpc.WithTransportCredentials(insecure.NewCredentials())} {
+		if isContextDialer(opt) {
+			t.Errorf("isContextDialer(%T) = true for an unrelated option", opt)
+		}
+	}
+}
@@ -7,13 +7,41 @@
 // All metrics use OpenTelemetry and can be exported to Prometheus. Metrics include:
 //
 //   - call_total_ms: Total RPC duration (end-to-end for unary, TTFB for streaming)
+//   - pick_wait_ms: Time from start to the first successful subchannel pick
+//   - picks_blocked: Counter of calls whose pick waited for a ready subchannel
 //   - stream_establish_ms: Time from start to first OutHeader (includes DNS, connect, queue)
 //   - send_stall_ms: Time from OutHeader to first OutPayload (flow control backpressure)
 //   - response_wait_ms: Time from first OutPayload to response (TTFB for streaming, end-to-end for unary)
 //   - attempts_per_call: Number of retry attempts per call
+//   - attempt_duration_ms, attempt_stream_establish_ms, attempt_response_wait_ms: The same
+//     phases per attempt, labeled with attempt_type (initial, retry, hedge); recorded
+//     with Config.Retry, Config.Hedging or Config.EnableAttemptMetrics
+//   - retries, retries_throttled: Retries made by Config.Retry, and denied by its budget
+//   - hedges_sent, hedges_won, hedges_wasted, hedges_throttled: Outcomes of Config.Hedging
+//   - circuit_breaker_state, circuit_breaker_transitions: Per-backend circuit breakers (Config.CircuitBreaker)
+//   - outlier_ejections, outliers_ejected: Backends ejected by Config.OutlierDetection
+//   - calls: Counter of finished calls
+//   - tcp_connect_ms, tls_handshake_ms: Per-connection establishment phases, with failure counters
+//   - connections_opened, connections_closed, connections_active, connection_lifetime_s: HTTP/2 connection lifecycle
+//   - resolver_latency_ms, resolver_errors, resolver_addresses(_added/_removed): Name resolution
 //   - tcp_rtt_ms, tcp_cwnd, tcp_retrans_delta: TCP-level diagnostics (Linux only)
+//   - tcp_rttvar_ms, tcp_min_rtt_ms, tcp_snd_ssthresh, tcp_unacked, tcp_lost, tcp_retrans,
+//     tcp_reordering, tcp_rcv_space, tcp_notsent_bytes, tcp_delivery_rate, tcp_pacing_rate:
+//     the rest of TCP_INFO (Linux only)
+//   - tcp_busy_time_ms, tcp_rwnd_limited_ms, tcp_sndbuf_limited_ms: Counters telling receiver
+//     backpressure (rwnd) apart from local buffering and congestion (Linux only)
+//   - tcp_conn_*: Latest TCP_INFO per live connection, labeled with conn_id
+//     (opt-in, see Config.PerConnectionTCPMetrics)
+//   - tcp_samples, tcp_samples_dropped: TCP sampler coverage, drops by reason
+//   - call_records_dropped: CallRecords not delivered to a slow CallObserver
+//   - slow_call_logs_suppressed: slow calls not logged (Config.SlowCallLog), by reason
+//   - conn_min_rtt_ms, conn_total_retrans, conn_bytes_sent, conn_bytes_acked: Final
+//     TCP_INFO snapshot of each closed connection (Linux only)
 //
-// All metrics are labeled with method (gRPC method name) and remote_ip (backend IP).
+// All call metrics are labeled with method (gRPC method name), remote_ip (backend IP),
+// grpc_code (e.g. OK, DEADLINE_EXCEEDED) and error_class (ok, client_cancel,
+// client_deadline, server, transport). TCP_INFO metrics are labeled with remote_ip
+// and trigger (periodic, slow_call, or close); see Config.SlowCall.
 //
 // # Quick Start
 //
@@ -39,6 +67,34 @@
 //	// Use cc as a normal *grpc.ClientConn
 //	// client := pb.NewMyServiceClient(cc)
 //
+// # Per-Client Options
+//
+// New accepts functional options applied on top of DefaultConfig, without
+// touching the process-wide default used by NewClient:
+//
+//	cc, err := rgrpc.New(ctx, "dns:///my-service:50051",
+//	    rgrpc.WithMetricPrefix("billing"),
+//	    rgrpc.WithLabels(attribute.String("upstream", "billing")),
+//	    rgrpc.WithDialOptions(grpc.WithTransportCredentials(insecure.NewCredentials())),
+//	)
+//
+// # Tracing
+//
+// With Config.EnableTracing (or WithTracing), each RPC gets a client span and
+// each attempt a child span. The call span carries the phase timestamps as
+// events (out_header, out_payload, in_header, in_payload), the remote IP,
+// attempt count and status. Attempt span contexts are propagated as W3C
+// traceparent metadata.
+//
+// # Debug Handler
+//
+// DebugHandler serves an HTML (or JSON) page listing every open client with its
+// live connections, latest TCP_INFO and per-method latency percentiles. With
+// WithCallHistory(true) it also shows the slowest recent calls and the most
+// recent failed calls:
+//
+//	http.Handle("/debug/rgrpc", rgrpc.DebugHandler())
+//
 // # Streaming Semantics
 //
 // For unary RPCs, metrics reflect true end-to-end call duration. For streaming
@@ -0,0 +1,152 @@
+package rgrpc
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"io"
+	"net"
+	"syscall"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Error classes used for the error_class metric attribute.
+const (
+	errorClassOK             = "ok"
+	errorClassClientCancel   = "client_cancel"
+	errorClassClientDeadline = "client_deadline"
+	errorClassServer         = "server"
+	errorClassTransport      = "transport"
+)
+
+// grpcCodeNames holds the canonical (proto enum) spelling of each status code.
+// codes.Code.String() uses CamelCase, which does not match what other gRPC
+// instrumentation and dashboards expect.
+var grpcCodeNames = [...]string{
+	codes.OK:                 "OK",
+	codes.Canceled:           "CANCELLED",
+	codes.Unknown:            "UNKNOWN",
+	codes.InvalidArgument:    "INVALID_ARGUMENT",
+	codes.DeadlineExceeded:   "DEADLINE_EXCEEDED",
+	codes.NotFound:           "NOT_FOUND",
+	codes.AlreadyExists:      "ALREADY_EXISTS",
+	codes.PermissionDenied:   "PERMISSION_DENIED",
+	codes.ResourceExhausted:  "RESOURCE_EXHAUSTED",
+	codes.FailedPrecondition: "FAILED_PRECONDITION",
+	codes.Aborted:            "ABORTED",
+	codes.OutOfRange:         "OUT_OF_RANGE",
+	codes.Unimplemented:      "UNIMPLEMENTED",
+	codes.Internal:           "INTERNAL",
+	codes.Unavailable:        "UNAVAILABLE",
+	codes.DataLoss:           "DATA_LOSS",
+	codes.Unauthenticated:    "UNAUTHENTICATED",
+}
+
+func grpcCodeName(c codes.Code) string {
+	if int(c) < len(grpcCodeNames) {
+		return grpcCodeNames[c]
+	}
+	return "UNKNOWN"
+}
+
+// classifyError decides who ended a failed call:
+//
+//   - client_cancel / client_deadline: the caller's own context ended the call.
+//   - server: the server answered (headers or trailers seen) with a non-OK status.
+//   - transport: the call failed without any response from the server
+//     (connection refused/reset, no ready backend, etc).
+//
+// With retries, only the last attempt counts: a retry that fails in the
+// transport after an earlier attempt got a server status is transport.
+func classifyError(ctx context.Context, st *callState, callErr error) (codes.Code, string) {
+	if callErr == nil {
+		return codes.OK, errorClassOK
+	}
+	code := status.Code(callErr)
+
+	if ctx != nil {
+		switch ctxErr := ctx.Err(); {
+		case errors.Is(ctxErr, context.DeadlineExceeded):
+			return code, errorClassClientDeadline
+		case errors.Is(ctxErr, context.Canceled):
+			return code, errorClassClientCancel
+		}
+	}
+
+	responded := st.inHeaderUnix.Load() > 0 || st.gotTrailer.Load()
+	if a := st.lastAttempt.Load(); a != nil {
+		responded = a.responded.Load()
+	}
+	if responded {
+		return code, errorClassServer
+	}
+	return code, errorClassTransport
+}
+
+// dialFailureCause buckets a connect error for the tcp_connect_failures counter.
+func dialFailureCause(ctx context.Context, err error) string {
+	if c := ctxFailureCause(ctx, err); c != "" {
+		return c
+	}
+	var dnsErr *net.DNSError
+	switch {
+	case errors.As(err, &dnsErr):
+		return "dns"
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return "refused"
+	case errors.Is(err, syscall.ECONNRESET):
+		return "reset"
+	case errors.Is(err, syscall.EHOSTUNREACH), errors.Is(err, syscall.ENETUNREACH):
+		return "unreachable"
+	}
+	var ne net.Error
+	if errors.As(err, &ne) && ne.Timeout() {
+		return "timeout"
+	}
+	return "other"
+}
+
+// handshakeFailureCause buckets a TLS handshake error for the tls_handshake_failures counter.
+func handshakeFailureCause(ctx context.Context, err error) string {
+	if c := ctxFailureCause(ctx, err); c != "" {
+		return c
+	}
+	var (
+		verifyErr   *tls.CertificateVerificationError
+		unknownAuth x509.UnknownAuthorityError
+		hostErr     x509.HostnameError
+		certErr     x509.CertificateInvalidError
+		alertErr    tls.AlertError
+		recordErr   tls.RecordHeaderError
+	)
+	switch {
+	case errors.As(err, &verifyErr), errors.As(err, &unknownAuth),
+		errors.As(err, &hostErr), errors.As(err, &certErr):
+		return "certificate"
+	case errors.As(err, &alertErr):
+		return "alert"
+	case errors.As(err, &recordErr):
+		return "protocol" // e.g. TLS client talking to a plaintext server
+	case errors.Is(err, io.EOF), errors.Is(err, syscall.ECONNRESET):
+		return "reset"
+	}
+	var ne net.Error
+	if errors.As(err, &ne) && ne.Timeout() {
+		return "timeout"
+	}
+	return "other"
+}
+
+// ctxFailureCause reports "canceled" or "timeout" when the attempt's context ended.
+func ctxFailureCause(ctx context.Context, err error) string {
+	switch {
+	case errors.Is(err, context.Canceled) || errors.Is(ctx.Err(), context.Canceled):
+		return "canceled"
+	case errors.Is(err, context.DeadlineExceeded) || errors.Is(ctx.Err(), context.DeadlineExceeded):
+		return "timeout"
+	}
+	return ""
+}
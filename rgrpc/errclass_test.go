@@ -0,0 +1,63 @@
+package rgrpc
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// TestClassifyError verifies that failed calls are attributed to the caller,
+// the server, or the transport.
+func TestClassifyError(t *testing.T) {
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	expired, cancel2 := context.WithTimeout(context.Background(), -time.Second)
+	defer cancel2()
+
+	tests := []struct {
+		name       string
+		ctx        context.Context
+		err        error
+		serverSeen bool
+		wantCode   codes.Code
+		wantClass  string
+	}{
+		{"ok", context.Background(), nil, true, codes.OK, errorClassOK},
+		{"client cancel", canceled, status.Error(codes.Canceled, "x"), false, codes.Canceled, errorClassClientCancel},
+		{"client deadline", expired, status.Error(codes.DeadlineExceeded, "x"), false, codes.DeadlineExceeded, errorClassClientDeadline},
+		{"server status", context.Background(), status.Error(codes.NotFound, "x"), true, codes.NotFound, errorClassServer},
+		{"server deadline", context.Background(), status.Error(codes.DeadlineExceeded, "x"), true, codes.DeadlineExceeded, errorClassServer},
+		{"transport", context.Background(), status.Error(codes.Unavailable, "x"), false, codes.Unavailable, errorClassTransport},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			st := &callState{}
+			if tt.serverSeen {
+				st.gotTrailer.Store(true)
+			}
+			code, class := classifyError(tt.ctx, st, tt.err)
+			if code != tt.wantCode || class != tt.wantClass {
+				t.Errorf("classifyError() = (%v, %q), want (%v, %q)", code, class, tt.wantCode, tt.wantClass)
+			}
+		})
+	}
+
+	// A retry that fails in the transport after an earlier attempt got a
+	// server status is classified by the last attempt.
+	st := &callState{}
+	first := newAttemptState(st)
+	first.responded.Store(true)
+	st.gotTrailer.Store(true)
+	st.lastAttempt.Store(newAttemptState(st))
+	if _, class := classifyError(context.Background(), st, status.Error(codes.Unavailable, "x")); class != errorClassTransport {
+		t.Errorf("retry without a response: class %q, want %q", class, errorClassTransport)
+	}
+
+	if got := grpcCodeName(codes.DeadlineExceeded); got != "DEADLINE_EXCEEDED" {
+		t.Errorf("grpcCodeName(DeadlineExceeded) = %q", got)
+	}
+}
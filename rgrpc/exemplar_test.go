@@ -0,0 +1,117 @@
+package rgrpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"testing"
+	"time"
+
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/stats"
+)
+
+// exemplarIDs returns the (trace ID, span ID) pairs of all exemplars on the named histogram.
+func exemplarIDs(t *testing.T, r sdkmetric.Reader, name string) [][2][]byte {
+	t.Helper()
+	var rm metricdata.ResourceMetrics
+	if err := r.Collect(context.Background(), &rm); err != nil {
+		t.Fatal(err)
+	}
+	var out [][2][]byte
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			if m.Name != name {
+				continue
+			}
+			hist, ok := m.Data.(metricdata.Histogram[float64])
+			if !ok {
+				t.Fatalf("%s is %T, want histogram", name, m.Data)
+			}
+			for _, dp := range hist.DataPoints {
+				for _, ex := range dp.Exemplars {
+					out = append(out, [2][]byte{ex.TraceID, ex.SpanID})
+				}
+			}
+		}
+	}
+	return out
+}
+
+// TestExemplarsCarryCallSpan verifies that call_total_ms and response_wait_ms
+// observations carry the call span's trace and span ID as exemplars, for both
+// unary calls and streams (where finalize runs in the per-attempt context).
+func TestExemplarsCarryCallSpan(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	rec := tracetest.NewSpanRecorder()
+
+	cfg := DefaultConfig()
+	cfg.TCPMetricsInterval = 0
+	cfg.EnableTracing = true
+	cfg.MeterProvider = sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+	cfg.TracerProvider = sdktrace.NewTracerProvider(
+		sdktrace.WithSampler(sdktrace.AlwaysSample()),
+		sdktrace.WithSpanProcessor(rec),
+	)
+
+	h := mustNewHooks(t, cfg)
+	defer h.close()
+	sh := newStatsHandler(h)
+
+	emit := func(ctx context.Context) {
+		ctx = sh.TagRPC(ctx, &stats.RPCTagInfo{})
+		sh.HandleRPC(ctx, &stats.OutHeader{})
+		sh.HandleRPC(ctx, &stats.OutPayload{SentTime: time.Now()})
+		sh.HandleRPC(ctx, &stats.InPayload{RecvTime: time.Now()})
+		sh.HandleRPC(ctx, &stats.End{EndTime: time.Now()})
+	}
+
+	ui := newUnaryInterceptor(h)
+	if err := ui(context.Background(), "/svc/Unary", nil, nil, nil,
+		func(ctx context.Context, _ string, _, _ any, _ *grpc.ClientConn, _ ...grpc.CallOption) error {
+			emit(ctx)
+			return nil
+		}); err != nil {
+		t.Fatal(err)
+	}
+
+	si := newStreamInterceptor(h)
+	if _, err := si(context.Background(), &grpc.StreamDesc{ServerStreams: true}, nil, "/svc/Stream",
+		func(ctx context.Context, _ *grpc.StreamDesc, _ *grpc.ClientConn, _ string, _ ...grpc.CallOption) (grpc.ClientStream, error) {
+			emit(ctx)
+			return nil, nil
+		}); err != nil {
+		t.Fatal(err)
+	}
+
+	callSpans := make(map[[2]string]bool)
+	for _, s := range rec.Ended() {
+		if s.Name() == "svc/Unary" || s.Name() == "svc/Stream" {
+			sc := s.SpanContext()
+			callSpans[[2]string{sc.TraceID().String(), sc.SpanID().String()}] = true
+		}
+	}
+	if len(callSpans) != 2 {
+		t.Fatalf("expected 2 call spans, got %d", len(callSpans))
+	}
+
+	for _, name := range []string{"rgrpc.call_total_ms", "rgrpc.response_wait_ms"} {
+		ids := exemplarIDs(t, reader, name)
+		if len(ids) != 2 {
+			t.Fatalf("%s: expected 2 exemplars, got %d", name, len(ids))
+		}
+		for _, id := range ids {
+			if bytes.Equal(id[0], make([]byte, 16)) {
+				t.Fatalf("%s: exemplar has empty trace ID", name)
+			}
+			key := [2]string{hex.EncodeToString(id[0]), hex.EncodeToString(id[1])}
+			if !callSpans[key] {
+				t.Errorf("%s: exemplar %v does not match a call span", name, key)
+			}
+		}
+	}
+}
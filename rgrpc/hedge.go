@@ -0,0 +1,258 @@
+package rgrpc
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/protobuf/proto"
+)
+
+// hedger decides which calls are hedged and after what delay. The delay is
+// the method's rolling latency percentile from methodLatencies, the same
+// estimate the slow-call detector uses.
+type hedger struct {
+	methods    map[string]bool // full methods and "/pkg.Service/" prefixes
+	percentile float64
+	minSamples uint64
+	minDelay   time.Duration
+	maxDelay   time.Duration
+	lat        *methodLatencies
+	budget     *retryBudget
+}
+
+// newHedger returns nil when no method is configured for hedging.
+func newHedger(cfg HedgingConfig, lat *methodLatencies) *hedger {
+	if len(cfg.Methods) == 0 {
+		return nil
+	}
+	hg := &hedger{
+		methods:    make(map[string]bool, len(cfg.Methods)),
+		percentile: cfg.Percentile,
+		minSamples: hedgeMinSamples(cfg),
+		minDelay:   cfg.MinDelay,
+		maxDelay:   cfg.MaxDelay,
+		lat:        lat,
+		budget:     newRetryBudget(cfg.Budget),
+	}
+	if hg.percentile == 0 {
+		hg.percentile = defaultHedgePercentile
+	}
+	for _, m := range cfg.Methods {
+		hg.methods[m] = true
+	}
+	return hg
+}
+
+func hedgeMinSamples(cfg HedgingConfig) uint64 {
+	if cfg.MinSamples > 0 {
+		return uint64(cfg.MinSamples)
+	}
+	return defaultHedgeMinSamples
+}
+
+// applies reports whether a call of method with this reply may be hedged: the
+// method is listed, and the reply can be cloned so each attempt decodes into
+// its own message.
+func (hg *hedger) applies(method string, reply any) bool {
+	if _, ok := reply.(proto.Message); !ok {
+		return false
+	}
+	if hg.methods[method] {
+		return true
+	}
+	i := strings.LastIndexByte(method, '/')
+	return i > 0 && hg.methods[method[:i+1]]
+}
+
+// delay returns how long to wait before hedging a call of method, or false
+// while the method has fewer than minSamples observations.
+func (hg *hedger) delay(method string) (time.Duration, bool) {
+	h := hg.lat.hist(method)
+	if h == nil {
+		return 0, false
+	}
+	q, ok := h.quantile(hg.percentile, hg.minSamples)
+	if !ok {
+		return 0, false
+	}
+	d := time.Duration(q * float64(time.Millisecond))
+	if d < hg.minDelay {
+		d = hg.minDelay
+	}
+	if hg.maxDelay > 0 && d > hg.maxDelay {
+		d = hg.maxDelay
+	}
+	return d, true
+}
+
+// hedgeAttempt is the duplicate of a call, run on its own goroutine with its
+// own callState, reply and call outputs.
+type hedgeAttempt struct {
+	st     *callState
+	reply  proto.Message
+	out    callOutputs
+	err    error
+	done   chan struct{}
+	cancel context.CancelFunc
+}
+
+// callOutputs is one attempt's copy of the values requested with grpc.Header,
+// grpc.Trailer and grpc.Peer. Concurrent attempts write their own copy; only
+// the winner's is handed to the caller.
+type callOutputs struct {
+	header  metadata.MD
+	trailer metadata.MD
+	peer    peer.Peer
+}
+
+// redirect returns opts with grpc.Header, grpc.Trailer and grpc.Peer pointing
+// at o instead of the caller's variables.
+func (o *callOutputs) redirect(opts []grpc.CallOption) []grpc.CallOption {
+	res := make([]grpc.CallOption, len(opts))
+	for i, opt := range opts {
+		switch opt.(type) {
+		case grpc.HeaderCallOption:
+			res[i] = grpc.Header(&o.header)
+		case grpc.TrailerCallOption:
+			res[i] = grpc.Trailer(&o.trailer)
+		case grpc.PeerCallOption:
+			res[i] = grpc.Peer(&o.peer)
+		default:
+			res[i] = opt
+		}
+	}
+	return res
+}
+
+// deliver copies o into the caller's variables named in opts.
+func (o *callOutputs) deliver(opts []grpc.CallOption) {
+	for _, opt := range opts {
+		switch opt := opt.(type) {
+		case grpc.HeaderCallOption:
+			*opt.HeaderAddr = o.header
+		case grpc.TrailerCallOption:
+			*opt.TrailerAddr = o.trailer
+		case grpc.PeerCallOption:
+			*opt.PeerAddr = o.peer
+		}
+	}
+}
+
+// newHedgeState returns the callState of a hedge of the call tracked by st.
+// It shares the call's start and span, so if the hedge wins, finalizing it
+// records the call as a whole, and it avoids the call's subchannel.
+func newHedgeState(st *callState) *callState {
+	hs := &callState{
+		method:    st.method,
+		startUnix: st.startUnix,
+		span:      st.span,
+		isHedge:   true,
+		avoid:     st.subConn.Load(),
+		breakers:  st.breakers,
+		outliers:  st.outliers,
+	}
+	return hs
+}
+
+// invokeHedged runs the call on the caller's goroutine and, if it has not
+// finished after the method's hedging delay, sends a hedge on another. The
+// first successful response wins and the other attempt is cancelled; if the
+// first to finish fails, the other is awaited. It returns only once both
+// attempts are done, so neither touches req, reply or the caller's call
+// options afterwards, and returns the callState to finalize (the winner's).
+//
+// Until the method has a hedging delay, the call goes through the retry
+// policy, if any, like an unhedged call.
+func (h *hooks) invokeHedged(ctx context.Context, st *callState, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) (*callState, error) {
+	hg := h.hedge
+	hg.budget.deposit()
+	d, ok := hg.delay(method)
+	if !ok {
+		if h.retry != nil {
+			return st, h.invokeWithRetry(ctx, method, req, reply, cc, invoker, opts...)
+		}
+		return st, invoker(ctx, method, req, reply, cc, opts...)
+	}
+	st.hedgeable = true
+
+	primaryCtx, cancelPrimary := context.WithCancel(ctx)
+	defer cancelPrimary()
+
+	// The hedge decodes into a new message of the reply's type; the caller's
+	// reply is only read after both attempts are done.
+	replyType := reply.(proto.Message).ProtoReflect().Type()
+
+	var (
+		mu       sync.Mutex
+		finished bool // the primary returned; no hedge may start
+		hedge    *hedgeAttempt
+	)
+	timer := time.AfterFunc(d, func() {
+		mu.Lock()
+		defer mu.Unlock()
+		if finished || ctx.Err() != nil {
+			return
+		}
+		if !hg.budget.withdraw() {
+			h.metrics.recordHedge(ctx, method, hedgeThrottled)
+			return
+		}
+		hs := newHedgeState(st)
+		hctx, cancel := context.WithCancel(context.WithValue(ctx, callStateKey{}, hs))
+		ha := &hedgeAttempt{
+			st:     hs,
+			reply:  replyType.New().Interface(),
+			done:   make(chan struct{}),
+			cancel: cancel,
+		}
+		hedge = ha
+		h.metrics.recordHedge(ctx, method, hedgeSent)
+
+		hopts := ha.out.redirect(opts)
+		go func() {
+			ha.err = invoker(hctx, method, req, ha.reply, cc, hopts...)
+			if ha.err == nil {
+				cancelPrimary()
+			}
+			close(ha.done)
+		}()
+	})
+
+	var out callOutputs
+	err := invoker(primaryCtx, method, req, reply, cc, out.redirect(opts)...)
+
+	mu.Lock()
+	finished = true
+	ha := hedge
+	mu.Unlock()
+	timer.Stop()
+
+	if ha == nil {
+		out.deliver(opts)
+		return st, err
+	}
+	if err == nil {
+		ha.cancel()
+	}
+	<-ha.done
+	ha.cancel()
+
+	if err == nil || ha.err != nil {
+		h.metrics.recordHedge(ctx, method, hedgeWasted)
+		st.attempts.Add(ha.st.attempts.Load())
+		out.deliver(opts)
+		return st, err
+	}
+	h.metrics.recordHedge(ctx, method, hedgeWon)
+	msg := reply.(proto.Message)
+	proto.Reset(msg)
+	proto.Merge(msg, ha.reply)
+	ha.out.deliver(opts)
+	ha.st.attempts.Add(st.attempts.Load())
+	return ha.st, nil
+}
@@ -0,0 +1,227 @@
+package rgrpc
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/resolver"
+	"google.golang.org/grpc/resolver/manual"
+	"google.golang.org/grpc/status"
+)
+
+// startDelayedHealthServer starts a health server that sends its address in
+// the "server" header and then sleeps for *delay (read per call) before
+// answering, and returns its address.
+func startDelayedHealthServer(t *testing.T, delay *atomic.Int64) string {
+	t.Helper()
+	return startHealthServerWith(t, "127.0.0.1", func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		// Headers go out before the delay, so a losing attempt has already
+		// received them when it is cancelled.
+		p, _ := peer.FromContext(ctx)
+		if err := grpc.SendHeader(ctx, metadata.Pairs("server", p.LocalAddr.String())); err != nil {
+			return nil, err
+		}
+		select {
+		case <-time.After(time.Duration(delay.Load())):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		return handler(ctx, req)
+	})
+}
+
+func withHedgingDelay(minSamples int, minDelay time.Duration) Option {
+	return func(o *clientOptions) {
+		o.cfg.Hedging.MinSamples = minSamples
+		o.cfg.Hedging.MinDelay = minDelay
+	}
+}
+
+// TestHedging verifies that once a method's latency is known, a call stuck on
+// a slow backend is hedged to the other one, the hedge's response is used,
+// and the call is recorded with the winning attempt.
+func TestHedging(t *testing.T) {
+	var slowDelay, fastDelay atomic.Int64
+	slowAddr := startDelayedHealthServer(t, &slowDelay)
+	fastAddr := startDelayedHealthServer(t, &fastDelay)
+
+	r := manual.NewBuilderWithScheme("rgrpchedge")
+	r.InitialState(resolver.State{Addresses: []resolver.Address{{Addr: slowAddr}, {Addr: fastAddr}}})
+
+	const method = "/grpc.health.v1.Health/Check"
+	records := make(chan CallRecord, 100)
+	reader := sdkmetric.NewManualReader()
+	cc, err := New(context.Background(), "rgrpchedge:///svc",
+		WithTCPSampling(0),
+		WithClientSideLB(true),
+		WithResolver(r),
+		WithHedging(method),
+		withHedgingDelay(10, 20*time.Millisecond),
+		WithCallObserver(CallObserverFunc(func(r CallRecord) { records <- r })),
+		WithMeterProvider(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))),
+		WithDialOptions(grpc.WithTransportCredentials(insecure.NewCredentials())),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cc.Close()
+	client := healthpb.NewHealthClient(cc)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	for i := 0; i < 20; i++ {
+		if _, err := client.Check(ctx, &healthpb.HealthCheckRequest{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i := 0; i < 20; i++ {
+		<-records
+	}
+
+	slowDelay.Store(int64(5 * time.Second))
+	// Round robin sends one of every two calls to the slow backend.
+	for i := 0; i < 4; i++ {
+		start := time.Now()
+		var md metadata.MD
+		var p peer.Peer
+		resp, err := client.Check(ctx, &healthpb.HealthCheckRequest{}, grpc.Header(&md), grpc.Peer(&p))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := md.Get("server"); len(got) != 1 || got[0] != fastAddr {
+			t.Errorf("call %d: header server = %v, want the winner's %s", i, got, fastAddr)
+		}
+		if p.Addr == nil || p.Addr.String() != fastAddr {
+			t.Errorf("call %d: peer = %v, want the winner's %s", i, p.Addr, fastAddr)
+		}
+		if resp.GetStatus() != healthpb.HealthCheckResponse_SERVING {
+			t.Errorf("reply not copied from the winning attempt: %v", resp)
+		}
+		if d := time.Since(start); d > time.Second {
+			t.Fatalf("call %d took %v; not hedged", i, d)
+		}
+		rec := <-records
+		if rec.Total > time.Second || rec.RemoteAddr != fastAddr {
+			t.Errorf("call %d recorded %v on %s, want the fast hedge on %s", i, rec.Total, rec.RemoteAddr, fastAddr)
+		}
+	}
+
+	if got := int64Value(t, reader, "rgrpc.hedges_won"); got < 2 {
+		t.Errorf("hedges_won = %d, want >= 2", got)
+	}
+	var sawHedge bool
+	for _, set := range seriesAttrs(t, reader, "rgrpc.attempt_duration_ms") {
+		if v, _ := set.Value(attribute.Key("attempt_type")); v.AsString() == attemptHedge {
+			sawHedge = true
+		}
+	}
+	if !sawHedge {
+		t.Error("no attempt recorded with attempt_type=hedge")
+	}
+}
+
+// TestHedgingReplyAtDelay verifies, under -race, that the hedge does not read
+// the caller's reply while the original attempt decodes its response into it,
+// whichever of the two happens first around the hedging delay.
+func TestHedgingReplyAtDelay(t *testing.T) {
+	const (
+		method = "/grpc.health.v1.Health/Check"
+		delay  = time.Millisecond
+	)
+	cfg := DefaultConfig()
+	cfg.TCPMetricsInterval = 0
+	cfg.Hedging = HedgingConfig{
+		Methods:    []string{method},
+		MinSamples: 1,
+		MinDelay:   delay,
+		MaxDelay:   delay,
+		Budget:     RetryBudget{Ratio: 1, MaxTokens: 100},
+	}
+	h := mustNewHooks(t, cfg)
+	defer h.close()
+	h.latencies.add(method, delay)
+
+	for i := 0; i < 20; i++ {
+		// The original attempt answers between 0.5 and 1.5 delays.
+		answerAfter := delay/2 + time.Duration(i)*delay/20
+		invoker := func(ctx context.Context, _ string, _, reply any, _ *grpc.ClientConn, _ ...grpc.CallOption) error {
+			if st, _ := ctx.Value(callStateKey{}).(*callState); st != nil && st.isHedge {
+				<-ctx.Done()
+				return status.FromContextError(ctx.Err()).Err()
+			}
+			time.Sleep(answerAfter)
+			reply.(*healthpb.HealthCheckResponse).Status = healthpb.HealthCheckResponse_SERVING
+			return nil
+		}
+		reply := &healthpb.HealthCheckResponse{}
+		st := &callState{method: method}
+		if _, err := h.invokeHedged(context.Background(), st, method, &healthpb.HealthCheckRequest{}, reply, nil, invoker); err != nil {
+			t.Fatal(err)
+		}
+		if reply.GetStatus() != healthpb.HealthCheckResponse_SERVING {
+			t.Fatalf("call %d: reply = %v", i, reply)
+		}
+	}
+}
+
+// TestHedgingRetriesBeforeMinSamples verifies that a hedged method still uses
+// the retry policy while it has no hedging delay.
+func TestHedgingRetriesBeforeMinSamples(t *testing.T) {
+	addr, calls := startFlakyHealthServer(t, 1, codes.Unavailable)
+	client := newRetryTestClient(t, addr, sdkmetric.NewManualReader(),
+		WithHedging("/grpc.health.v1.Health/"),
+		WithRetryPolicy(RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond}),
+	)
+
+	if _, err := client.Check(context.Background(), &healthpb.HealthCheckRequest{}); err != nil {
+		t.Fatalf("call failed despite retries: %v", err)
+	}
+	if got := calls.Load(); got != 2 {
+		t.Errorf("server saw %d calls, want 2", got)
+	}
+}
+
+func TestHedgerAppliesAndDelay(t *testing.T) {
+	lat := newMethodLatencies(0)
+	hg := newHedger(HedgingConfig{
+		Methods:    []string{"/svc.A/Get", "/svc.B/"},
+		MinSamples: 10,
+		MaxDelay:   50 * time.Millisecond,
+	}, lat)
+
+	reply := &healthpb.HealthCheckResponse{}
+	if !hg.applies("/svc.A/Get", reply) || !hg.applies("/svc.B/List", reply) || hg.applies("/svc.A/Put", reply) {
+		t.Error("method matching")
+	}
+	if hg.applies("/svc.A/Get", struct{}{}) {
+		t.Error("non-proto reply must not be hedged")
+	}
+
+	if _, ok := hg.delay("/svc.A/Get"); ok {
+		t.Error("delay available before MinSamples")
+	}
+	for i := 0; i < 100; i++ {
+		lat.add("/svc.A/Get", 10*time.Millisecond)
+		lat.add("/svc.B/List", time.Second)
+	}
+	if d, ok := hg.delay("/svc.A/Get"); !ok || d < 5*time.Millisecond || d > 20*time.Millisecond {
+		t.Errorf("delay = %v, %v; want about 10ms", d, ok)
+	}
+	if d, _ := hg.delay("/svc.B/List"); d != 50*time.Millisecond {
+		t.Errorf("delay = %v, want MaxDelay", d)
+	}
+
+	if newHedger(HedgingConfig{}, lat) != nil {
+		t.Error("expected nil hedger without methods")
+	}
+}
@@ -4,6 +4,9 @@ import (
 	"context"
 	"net"
 	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/metric"
 )
 
 type hooks struct {
@@ -12,21 +15,61 @@ type hooks struct {
 	pool sync.Pool
 
 	metrics *metrics
+	tracing *tracing          // nil when tracing is disabled
+	slow    *slowCallDetector // nil when slow-call sampling is disabled
+	slowLog *slowCallLogger   // nil when slow-call logging is disabled
+	retry   *retrier          // nil when retries are disabled
+	hedge   *hedger           // nil when hedging is disabled
+
+	attemptMetrics bool // record the attempt_* histograms
+
+	breakers *breakerSet      // nil when circuit breaking is disabled
+	outliers *outlierDetector // nil when outlier detection is disabled
+
+	target    string           // set by newClient; shown by DebugHandler
+	latencies *methodLatencies // rolling per-method latency
+	history   *callHistory     // nil unless Config.EnableCallHistory
+	observer  *callDispatcher  // nil unless Config.CallObserver is set
 
 	reg  *connRegistry
 	diag *diagWorker
 
+	connGauges metric.Registration // nil unless Config.PerConnectionTCPMetrics
+
 	stopCh chan struct{}
 }
 
-func newHooks(cfg Config) *hooks {
+func newHooks(cfg Config) (*hooks, error) {
 	h := &hooks{cfg: cfg}
 	h.stopCh = make(chan struct{})
 
 	h.pool.New = func() any { return &callState{} }
 
-	h.metrics = newMetrics(cfg)
-	h.reg = newConnRegistry()
+	m, err := newMetrics(cfg)
+	if err != nil {
+		return nil, err
+	}
+	h.metrics = m
+	h.tracing = newTracing(cfg)
+	h.latencies = newMethodLatencies(max(latencyWindow(cfg.SlowCall), 4*hedgeMinSamples(cfg.Hedging)))
+	h.slow = newSlowCallDetector(cfg.SlowCall, h.latencies)
+	h.slowLog = newSlowCallLogger(cfg.SlowCallLog, h.metrics)
+	h.retry = newRetrier(cfg.Retry)
+	h.hedge = newHedger(cfg.Hedging, h.latencies)
+	h.attemptMetrics = cfg.EnableAttemptMetrics || h.retry != nil || h.hedge != nil
+	h.breakers = newBreakerSet(cfg.CircuitBreaker, h.metrics)
+	h.outliers = newOutlierDetector(cfg.OutlierDetection, h.metrics, h.stopCh)
+	if cfg.EnableCallHistory {
+		h.history = newCallHistory(debugCallHistory, debugSlowestWindow)
+	}
+	h.observer = newCallDispatcher(cfg, h.metrics, h.stopCh)
+	h.reg = newConnRegistry(h.connClosed)
+
+	if cfg.PerConnectionTCPMetrics {
+		if h.connGauges, err = registerConnGauges(h.metrics, h.reg); err != nil {
+			return nil, err
+		}
+	}
 
 	// One shared worker for TCP_INFO sampling (on-demand and periodic enqueue).
 	h.diag = newDiagWorker(cfg, h.reg, h.metrics, h.stopCh)
@@ -36,7 +79,23 @@ func newHooks(cfg Config) *hooks {
 		startTCPSampler(cfg, h.reg, h.diag, h.stopCh)
 	}
 
-	return h
+	return h, nil
+}
+
+// connClosed emits the connection-summary metrics for a closed connection and
+// hands the summary to the user callback.
+func (h *hooks) connClosed(ci *connInfo, final TCPInfoSummary, d tcpDeltas) {
+	sum := ConnectionSummary{
+		LocalAddr:  ci.local,
+		RemoteAddr: ci.remote,
+		RemoteIP:   ci.remoteIP,
+		Lifetime:   time.Since(time.Unix(0, ci.createdUnix)),
+		TCP:        final,
+	}
+	h.metrics.recordConnSummary(context.Background(), sum, d)
+	if h.cfg.OnConnectionClosed != nil {
+		h.cfg.OnConnectionClosed(sum)
+	}
 }
 
 func (h *hooks) close() {
@@ -49,13 +108,28 @@ func (h *hooks) close() {
 	if h.diag != nil {
 		h.diag.stop()
 	}
+	if h.connGauges != nil {
+		_ = h.connGauges.Unregister()
+	}
+	unregisterDebugClient(h)
 }
 
 func (h *hooks) dial(ctx context.Context, addr string) (net.Conn, error) {
-	c, err := defaultDial(ctx, addr)
+	dial := h.cfg.Dialer
+	if dial == nil {
+		dial = defaultDial
+	}
+	start := time.Now()
+	c, err := dial(ctx, addr)
 	if err != nil {
+		h.metrics.recordConnectFailure(ctx, hostFromAddr(addr), dialFailureCause(ctx, err))
 		return nil, err
 	}
+	remoteIP := ipStringFromAddr(c.RemoteAddr())
+	if remoteIP == "" {
+		remoteIP = "unknown"
+	}
+	h.metrics.recordConnect(ctx, remoteIP, time.Since(start))
 	return h.reg.wrapConn(ctx, c), nil
 }
 
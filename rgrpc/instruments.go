@@ -0,0 +1,154 @@
+package rgrpc
+
+import (
+	"fmt"
+
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/metric/noop"
+)
+
+// Default bucket boundaries, chosen per metric shape instead of the OTel
+// default (0..10000), which puts every sub-millisecond call in one bucket.
+var (
+	latencyBucketsMs = []float64{
+		0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 25, 50, 100, 250, 500,
+		1000, 2500, 5000, 10000, 30000, 60000,
+	}
+	attemptBuckets = []float64{1, 2, 3, 4, 5, 10}
+	segmentBuckets = []float64{1, 2, 4, 8, 16, 32, 64, 128, 256, 512, 1024, 4096}
+	retransBuckets = []float64{0, 1, 2, 5, 10, 25, 50, 100, 500, 1000}
+	// Connections live from seconds (churn) to days (healthy long-lived HTTP/2).
+	lifetimeBucketsS = []float64{1, 5, 15, 30, 60, 300, 900, 1800, 3600, 7200, 21600, 86400}
+	// Powers of 4 from 1 KiB to 4 GiB.
+	byteBuckets = []float64{1 << 10, 1 << 12, 1 << 14, 1 << 16, 1 << 18, 1 << 20, 1 << 22, 1 << 24, 1 << 26, 1 << 28, 1 << 30, 1 << 32}
+)
+
+// defaultBuckets maps each histogram (name without prefix) to its default
+// explicit bucket boundaries. It is also the list of names accepted as keys
+// in Config.HistogramBuckets.
+var defaultBuckets = map[string][]float64{
+	"call_total_ms":               latencyBucketsMs,
+	"stream_establish_ms":         latencyBucketsMs,
+	"send_stall_ms":               latencyBucketsMs,
+	"response_wait_ms":            latencyBucketsMs,
+	"attempts_per_call":           attemptBuckets,
+	"pick_wait_ms":                latencyBucketsMs,
+	"attempt_duration_ms":         latencyBucketsMs,
+	"attempt_stream_establish_ms": latencyBucketsMs,
+	"attempt_response_wait_ms":    latencyBucketsMs,
+	"tcp_rtt_ms":                  latencyBucketsMs,
+	"tcp_cwnd":                    segmentBuckets,
+	"tcp_retrans_delta":           retransBuckets,
+	"tcp_rttvar_ms":               latencyBucketsMs,
+	"tcp_min_rtt_ms":              latencyBucketsMs,
+	"tcp_snd_ssthresh":            segmentBuckets,
+	"tcp_unacked":                 segmentBuckets,
+	"tcp_lost":                    retransBuckets,
+	"tcp_retrans":                 retransBuckets,
+	"tcp_reordering":              retransBuckets,
+	"tcp_rcv_space":               byteBuckets,
+	"tcp_notsent_bytes":           byteBuckets,
+	"tcp_delivery_rate":           byteBuckets,
+	"tcp_pacing_rate":             byteBuckets,
+	"tcp_connect_ms":              latencyBucketsMs,
+	"tls_handshake_ms":            latencyBucketsMs,
+	"connection_lifetime_s":       lifetimeBucketsS,
+	"conn_min_rtt_ms":             latencyBucketsMs,
+	"conn_total_retrans":          retransBuckets,
+	"conn_bytes_sent":             byteBuckets,
+	"conn_bytes_acked":            byteBuckets,
+	"resolver_latency_ms":         latencyBucketsMs,
+}
+
+// instrumentBuilder creates instruments named prefix+"."+name and remembers the
+// first creation error, so newMetrics can create everything and check once.
+// Failed instruments are replaced by no-ops so callers never see nil.
+type instrumentBuilder struct {
+	meter   metric.Meter
+	prefix  string
+	buckets map[string][]float64 // user overrides (Config.HistogramBuckets)
+	err     error
+}
+
+func (b *instrumentBuilder) hist(name, unit, desc string) metric.Float64Histogram {
+	bounds, ok := b.buckets[name]
+	if !ok {
+		bounds = defaultBuckets[name]
+	}
+	h, err := b.meter.Float64Histogram(b.prefix+"."+name,
+		metric.WithUnit(unit),
+		metric.WithDescription(desc),
+		metric.WithExplicitBucketBoundaries(bounds...),
+	)
+	if err != nil {
+		b.fail(name, err)
+		return noop.Float64Histogram{}
+	}
+	return h
+}
+
+func (b *instrumentBuilder) counter(name, unit, desc string) metric.Int64Counter {
+	c, err := b.meter.Int64Counter(b.prefix+"."+name,
+		metric.WithUnit(unit),
+		metric.WithDescription(desc),
+	)
+	if err != nil {
+		b.fail(name, err)
+		return noop.Int64Counter{}
+	}
+	return c
+}
+
+func (b *instrumentBuilder) floatCounter(name, unit, desc string) metric.Float64Counter {
+	c, err := b.meter.Float64Counter(b.prefix+"."+name,
+		metric.WithUnit(unit),
+		metric.WithDescription(desc),
+	)
+	if err != nil {
+		b.fail(name, err)
+		return noop.Float64Counter{}
+	}
+	return c
+}
+
+func (b *instrumentBuilder) upDownCounter(name, unit, desc string) metric.Int64UpDownCounter {
+	c, err := b.meter.Int64UpDownCounter(b.prefix+"."+name,
+		metric.WithUnit(unit),
+		metric.WithDescription(desc),
+	)
+	if err != nil {
+		b.fail(name, err)
+		return noop.Int64UpDownCounter{}
+	}
+	return c
+}
+
+func (b *instrumentBuilder) gauge(name, unit, desc string) metric.Int64Gauge {
+	g, err := b.meter.Int64Gauge(b.prefix+"."+name,
+		metric.WithUnit(unit),
+		metric.WithDescription(desc),
+	)
+	if err != nil {
+		b.fail(name, err)
+		return noop.Int64Gauge{}
+	}
+	return g
+}
+
+func (b *instrumentBuilder) observableGauge(name, unit, desc string) metric.Float64ObservableGauge {
+	g, err := b.meter.Float64ObservableGauge(b.prefix+"."+name,
+		metric.WithUnit(unit),
+		metric.WithDescription(desc),
+	)
+	if err != nil {
+		b.fail(name, err)
+		return noop.Float64ObservableGauge{}
+	}
+	return g
+}
+
+func (b *instrumentBuilder) fail(name string, err error) {
+	if b.err == nil {
+		b.err = fmt.Errorf("create instrument %s.%s: %w", b.prefix, name, err)
+	}
+}
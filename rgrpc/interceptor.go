@@ -4,6 +4,7 @@ import (
 	"context"
 	"time"
 
+	"go.opentelemetry.io/otel/trace"
 	"google.golang.org/grpc"
 )
 
@@ -13,9 +14,28 @@ func newUnaryInterceptor(h *hooks) grpc.UnaryClientInterceptor {
 		st.reset()
 		st.method = method
 		st.startUnix = unixNow()
+		st.breakers = h.breakers
+		st.outliers = h.outliers
 
+		if h.tracing != nil {
+			ctx = h.tracing.startCall(ctx, st)
+		}
 		ctx = context.WithValue(ctx, callStateKey{}, st)
-		err := invoker(ctx, method, req, reply, cc, opts...)
+		var err error
+		switch {
+		case h.hedge != nil && h.hedge.applies(method, reply):
+			// Both attempts are done when invokeHedged returns, so st can
+			// be pooled even if the hedge won.
+			var winner *callState
+			winner, err = h.invokeHedged(ctx, st, method, req, reply, cc, invoker, opts...)
+			h.finalize(ctx, winner, err)
+			h.pool.Put(st)
+			return err
+		case h.retry != nil:
+			err = h.invokeWithRetry(ctx, method, req, reply, cc, invoker, opts...)
+		default:
+			err = invoker(ctx, method, req, reply, cc, opts...)
+		}
 
 		// finalize (invoker blocks until RPC is complete for unary)
 		h.finalize(ctx, st, err)
@@ -27,19 +47,26 @@ func newUnaryInterceptor(h *hooks) grpc.UnaryClientInterceptor {
 
 func newStreamInterceptor(h *hooks) grpc.StreamClientInterceptor {
 	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
-		st := h.pool.Get().(*callState)
-		st.reset()
+		// Streaming states are not pooled: stats.End may arrive more than once
+		// (one per attempt) and at any time after the streamer returns, so the
+		// state must stay valid until it is garbage collected.
+		st := &callState{}
 		st.method = method
 		st.startUnix = unixNow()
 		st.isStreaming = true // Mark as streaming RPC
+		st.breakers = h.breakers
+		st.outliers = h.outliers
 
+		if h.tracing != nil {
+			ctx = h.tracing.startCall(ctx, st)
+		}
 		ctx = context.WithValue(ctx, callStateKey{}, st)
 
 		stream, err := streamer(ctx, desc, cc, method, opts...)
 		if err != nil {
-			// Stream creation failed, finalize immediately
+			// Stream creation failed, finalize immediately (no-op if stats.End
+			// already did). stats.End may never come if no attempt was started.
 			h.finalize(ctx, st, err)
-			h.pool.Put(st)
 			return nil, err
 		}
 
@@ -51,16 +78,21 @@ func newStreamInterceptor(h *hooks) grpc.StreamClientInterceptor {
 }
 
 func (h *hooks) finalize(ctx context.Context, st *callState, callErr error) {
+	if !st.finalized.CompareAndSwap(false, true) {
+		return
+	}
+
 	start := time.Unix(0, st.startUnix)
 
-	attempts := st.attempts.Load()
+	p := callPhases{
+		attempts:    st.attempts.Load(),
+		pickBlocked: st.pickBlocked.Load(),
+	}
 
 	oh := st.outHeaderUnix.Load()
 	op := st.outPayloadUnix.Load()
 	ip := st.inPayloadUnix.Load() // TTFB marker (first InPayload)
 
-	var total, streamEstablish, sendStall, responseWait time.Duration
-
 	// Determine end time: use TTFB for streaming, end-to-end for unary
 	var endUnix int64
 	if st.isStreaming {
@@ -82,29 +114,63 @@ func (h *hooks) finalize(ctx context.Context, st *callState, callErr error) {
 		}
 	}
 	end := time.Unix(0, endUnix)
-	total = end.Sub(start)
+	p.total = end.Sub(start)
 
-	// stream_establish: start -> outheader (folds dns/pick/connect/queue for Step 1)
+	// pick_wait: start -> first successful pick (set by rgrpc's balancer wrapper)
+	if pk := st.pickUnix.Load(); pk > 0 {
+		p.pickWait = time.Unix(0, pk).Sub(start)
+	}
+	// stream_establish: start -> outheader (folds dns/pick/connect/queue)
 	if oh > 0 {
-		streamEstablish = time.Unix(0, oh).Sub(start)
+		p.streamEstablish = time.Unix(0, oh).Sub(start)
 	}
 	// send_stall: outheader -> outpayload
 	if oh > 0 && op > 0 && op >= oh {
-		sendStall = time.Unix(0, op).Sub(time.Unix(0, oh))
+		p.sendStall = time.Unix(0, op).Sub(time.Unix(0, oh))
 	}
 	// response_wait: outpayload -> TTFB (streaming) or end (unary)
 	if op > 0 {
 		if st.isStreaming && ip > 0 {
 			// Streaming: measure to first response (TTFB)
-			responseWait = time.Unix(0, ip).Sub(time.Unix(0, op))
+			p.responseWait = time.Unix(0, ip).Sub(time.Unix(0, op))
 		} else if endUnix > 0 && endUnix >= op {
 			// Unary: measure to end (true end-to-end)
-			responseWait = end.Sub(time.Unix(0, op))
+			p.responseWait = end.Sub(time.Unix(0, op))
 		}
 	}
 
-	// Always emit call metrics. TCP metrics are sampled independently via periodic sampling.
-	h.metrics.recordCall(ctx, st.method, st, total, streamEstablish, sendStall, responseWait, attempts)
+	code, errClass := classifyError(ctx, st, callErr)
 
-	_ = callErr
+	// Record against the call span so histogram exemplars link to the call's
+	// trace. For streams, ctx is the per-attempt context from the stats handler
+	// and would otherwise point at the attempt span.
+	if st.span != nil {
+		ctx = trace.ContextWithSpan(ctx, st.span)
+	}
+
+	// Always emit call metrics. TCP metrics are sampled periodically, and on
+	// demand for the connection of a slow call.
+	h.metrics.recordCall(ctx, st.method, st, code, errClass, p)
+
+	if h.slow != nil {
+		if limit, slow := h.slow.limit(st.method, p.total); slow {
+			if la, ra := st.localTCP.Load(), st.remoteTCP.Load(); la != nil && ra != nil {
+				h.diag.enqueueSlowCall(la.String(), ra.String(), ipStringFromTCPAddr(ra))
+			}
+			if h.slowLog != nil {
+				h.slowLog.log(ctx, h.target, st, code, errClass, callErr, p, limit)
+			}
+		}
+	}
+	h.latencies.add(st.method, p.total)
+	if h.history != nil {
+		h.history.offer(h.target, st, code, errClass, callErr, p)
+	}
+	if h.observer != nil {
+		h.observer.offer(ctx, newCallRecord(h.target, st, code, errClass, callErr, p))
+	}
+
+	if h.tracing != nil {
+		h.tracing.endCall(st, code, errClass, callErr)
+	}
 }
@@ -33,3 +33,13 @@ func trimIPv6Brackets(host string) string {
 	}
 	return host
 }
+
+// hostFromAddr returns the host part of a dial address ("10.0.0.1:443" -> "10.0.0.1"),
+// or the address itself if it has no port.
+func hostFromAddr(addr string) string {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return addr
+	}
+	return trimIPv6Brackets(host)
+}
@@ -2,12 +2,14 @@ package rgrpc
 
 import (
 	"context"
+	"crypto/tls"
 	"sync"
 	"time"
 
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/metric"
+	"google.golang.org/grpc/codes"
 )
 
 type metrics struct {
@@ -20,31 +22,131 @@ type metrics struct {
 	hSendStall       metric.Float64Histogram
 	hResponseWait    metric.Float64Histogram
 	hAttempts        metric.Float64Histogram
+	hPickWait        metric.Float64Histogram
+
+	// Calls whose pick had to wait for a ready subchannel
+	cPicksBlocked metric.Int64Counter
+
+	// Call counter (one per finished call, labeled with status)
+	cCalls metric.Int64Counter
+
+	// Per-attempt histograms (ms) and retries
+	hAttemptDuration        metric.Float64Histogram
+	hAttemptStreamEstablish metric.Float64Histogram
+	hAttemptResponseWait    metric.Float64Histogram
+	cRetries                metric.Int64Counter
+	cRetriesThrottled       metric.Int64Counter
+
+	// Hedging outcomes
+	cHedgesSent      metric.Int64Counter
+	cHedgesWon       metric.Int64Counter
+	cHedgesWasted    metric.Int64Counter
+	cHedgesThrottled metric.Int64Counter
+
+	// Circuit breakers
+	gBreakerState       metric.Int64Gauge
+	cBreakerTransitions metric.Int64Counter
+
+	// Outlier detection
+	cOutlierEjections metric.Int64Counter
+	gOutliersEjected  metric.Int64Gauge
 
 	// TCP histograms
 	hTCPRttMs        metric.Float64Histogram
 	hTCPCwnd         metric.Float64Histogram
 	hTCPRetransDelta metric.Float64Histogram
-
-	// bounded caches of RecordOptions (avoid per-call attribute allocations)
-	callCache callOptCache
-	tcpCache  tcpOptCache
+	hTCPRttVar       metric.Float64Histogram
+	hTCPMinRTT       metric.Float64Histogram
+	hTCPSsthresh     metric.Float64Histogram
+	hTCPUnacked      metric.Float64Histogram
+	hTCPLost         metric.Float64Histogram
+	hTCPRetrans      metric.Float64Histogram
+	hTCPReordering   metric.Float64Histogram
+	hTCPRcvSpace     metric.Float64Histogram
+	hTCPNotsent      metric.Float64Histogram
+	hTCPDelivery     metric.Float64Histogram
+	hTCPPacing       metric.Float64Histogram
+
+	// TCP sampler coverage
+	cTCPSamples        metric.Int64Counter
+	cTCPSamplesDropped metric.Int64Counter
+
+	// Slow-call log records not written
+	cSlowCallLogsSuppressed metric.Int64Counter
+
+	// Call records not delivered to the CallObserver
+	cCallRecordsDropped metric.Int64Counter
+
+	// TCP_INFO limited-time counters (ms, summed from per-sample deltas)
+	cTCPBusy          metric.Float64Counter
+	cTCPRwndLimited   metric.Float64Counter
+	cTCPSndbufLimited metric.Float64Counter
+
+	// Connection establishment (per new connection, not per call)
+	hTCPConnect      metric.Float64Histogram
+	hTLSHandshake    metric.Float64Histogram
+	cConnectFailures metric.Int64Counter
+	cTLSFailures     metric.Int64Counter
+
+	// HTTP/2 connection lifecycle (stats.ConnBegin/ConnEnd)
+	cConnsOpened  metric.Int64Counter
+	cConnsClosed  metric.Int64Counter
+	udConnsActive metric.Int64UpDownCounter
+	hConnLifetime metric.Float64Histogram
+
+	// Final TCP_INFO snapshot of each closed connection (lifetime totals)
+	hConnMinRTT       metric.Float64Histogram
+	hConnTotalRetrans metric.Float64Histogram
+	hConnBytesSent    metric.Float64Histogram
+	hConnBytesAcked   metric.Float64Histogram
+
+	// Name resolution (per resolver update, not per call)
+	hResolverLatency   metric.Float64Histogram
+	cResolverErrors    metric.Int64Counter
+	gResolverAddresses metric.Int64Gauge
+	cResolverAdded     metric.Int64Counter
+	cResolverRemoved   metric.Int64Counter
+
+	// bounded caches of MeasurementOptions (avoid per-call attribute allocations)
+	callCache    callOptCache
+	attemptCache attemptOptCache
+	tcpCache     tcpOptCache
 }
 
 type callAttrKey struct {
 	method   string
 	remoteIP string
+	code     codes.Code
+	errClass string
 }
 
 type callOptCache struct {
 	mu  sync.Mutex
-	m   map[callAttrKey]metric.RecordOption
+	m   map[callAttrKey]metric.MeasurementOption
+	max int
+}
+
+type attemptAttrKey struct {
+	method      string
+	remoteIP    string
+	code        codes.Code
+	attemptType string
+}
+
+type attemptOptCache struct {
+	mu  sync.Mutex
+	m   map[attemptAttrKey]metric.MeasurementOption
 	max int
 }
 
+type tcpAttrKey struct {
+	remoteIP string
+	trigger  string
+}
+
 type tcpOptCache struct {
 	mu  sync.Mutex
-	m   map[string]metric.RecordOption // key: remoteIP (or "" if label disabled)
+	m   map[tcpAttrKey]metric.MeasurementOption
 	max int
 }
 
@@ -52,68 +154,338 @@ const (
 	maxAttrCacheSize = 4096
 )
 
-func newMetrics(cfg Config) *metrics {
+func newMetrics(cfg Config) (*metrics, error) {
 	m := &metrics{cfg: cfg}
-	mp := otel.GetMeterProvider()
+	mp := cfg.MeterProvider
+	if mp == nil {
+		// The global provider delegates to whatever provider is installed later
+		// via otel.SetMeterProvider, including for instruments created below.
+		mp = otel.GetMeterProvider()
+	}
 	m.meter = mp.Meter(cfg.MetricPrefix)
 
-	m.hTotal = mustHist(m.meter, cfg.MetricPrefix+".call_total_ms")
-	m.hStreamEstablish = mustHist(m.meter, cfg.MetricPrefix+".stream_establish_ms")
-	m.hSendStall = mustHist(m.meter, cfg.MetricPrefix+".send_stall_ms")
-	m.hResponseWait = mustHist(m.meter, cfg.MetricPrefix+".response_wait_ms")
-	m.hAttempts = mustHist(m.meter, cfg.MetricPrefix+".attempts_per_call")
-
-	m.hTCPRttMs = mustHist(m.meter, cfg.MetricPrefix+".tcp_rtt_ms")
-	m.hTCPCwnd = mustHist(m.meter, cfg.MetricPrefix+".tcp_cwnd")
-	m.hTCPRetransDelta = mustHist(m.meter, cfg.MetricPrefix+".tcp_retrans_delta")
+	b := &instrumentBuilder{meter: m.meter, prefix: cfg.MetricPrefix, buckets: cfg.HistogramBuckets}
+
+	m.hTotal = b.hist("call_total_ms", "ms", "Call duration: end-to-end for unary, time to first response for streams")
+	m.hStreamEstablish = b.hist("stream_establish_ms", "ms", "Time from call start to request headers sent (resolution, pick, connect, stream creation)")
+	m.hSendStall = b.hist("send_stall_ms", "ms", "Time from request headers sent to first request message sent (flow control)")
+	m.hResponseWait = b.hist("response_wait_ms", "ms", "Time from first request message sent to response (end for unary, first message for streams)")
+	m.hAttempts = b.hist("attempts_per_call", "{attempt}", "Transport attempts per call, including retries")
+	m.cCalls = b.counter("calls", "{call}", "Finished calls")
+	m.hPickWait = b.hist("pick_wait_ms", "ms", "Time from call start to the first successful subchannel pick")
+	m.cPicksBlocked = b.counter("picks_blocked", "{call}", "Calls whose pick had to wait because no subchannel was ready")
+
+	m.hAttemptDuration = b.hist("attempt_duration_ms", "ms", "Duration of one attempt, from stats.Begin to stats.End")
+	m.hAttemptStreamEstablish = b.hist("attempt_stream_establish_ms", "ms", "Time from attempt start to its request headers sent")
+	m.hAttemptResponseWait = b.hist("attempt_response_wait_ms", "ms", "Time from the attempt's first request message to its end (unary) or first response (streams)")
+	m.cRetries = b.counter("retries", "{attempt}", "Retries made by rgrpc's retry policy, by the status code that was retried")
+	m.cRetriesThrottled = b.counter("retries_throttled", "{call}", "Retries not made because the retry budget was exhausted")
+
+	m.cHedgesSent = b.counter("hedges_sent", "{call}", "Hedged duplicates sent")
+	m.cHedgesWon = b.counter("hedges_won", "{call}", "Hedges whose response was used")
+	m.cHedgesWasted = b.counter("hedges_wasted", "{call}", "Hedges whose response was not used (the original call answered first, or both failed)")
+	m.cHedgesThrottled = b.counter("hedges_throttled", "{call}", "Hedges not sent because the hedging budget was exhausted")
+
+	m.gBreakerState = b.gauge("circuit_breaker_state", "1", "Circuit breaker state of a backend: 0 closed, 1 open, 2 half-open")
+	m.cBreakerTransitions = b.counter("circuit_breaker_transitions", "{transition}", "Circuit breaker state changes, by the state entered")
+
+	m.cOutlierEjections = b.counter("outlier_ejections", "{ejection}", "Backends ejected by outlier detection, by reason (success_rate, latency)")
+	m.gOutliersEjected = b.gauge("outliers_ejected", "{backend}", "Backends currently ejected by outlier detection")
+
+	m.hTCPRttMs = b.hist("tcp_rtt_ms", "ms", "Smoothed TCP round-trip time (TCP_INFO rtt)")
+	m.hTCPCwnd = b.hist("tcp_cwnd", "{segment}", "TCP congestion window (TCP_INFO snd_cwnd)")
+	m.hTCPRetransDelta = b.hist("tcp_retrans_delta", "{segment}", "TCP segments retransmitted since the previous sample")
+	m.hTCPRttVar = b.hist("tcp_rttvar_ms", "ms", "TCP RTT variance (TCP_INFO rttvar)")
+	m.hTCPMinRTT = b.hist("tcp_min_rtt_ms", "ms", "Lowest TCP RTT seen so far on the connection (TCP_INFO min_rtt)")
+	m.hTCPSsthresh = b.hist("tcp_snd_ssthresh", "{segment}", "TCP slow start threshold, recorded once out of slow start (TCP_INFO snd_ssthresh)")
+	m.hTCPUnacked = b.hist("tcp_unacked", "{segment}", "TCP segments in flight (TCP_INFO unacked)")
+	m.hTCPLost = b.hist("tcp_lost", "{segment}", "TCP segments presumed lost (TCP_INFO lost)")
+	m.hTCPRetrans = b.hist("tcp_retrans", "{segment}", "Retransmitted TCP segments not yet acknowledged (TCP_INFO retrans)")
+	m.hTCPReordering = b.hist("tcp_reordering", "{segment}", "TCP reordering degree (TCP_INFO reordering)")
+	m.hTCPRcvSpace = b.hist("tcp_rcv_space", "By", "TCP receive buffer autotuning estimate (TCP_INFO rcv_space)")
+	m.hTCPNotsent = b.hist("tcp_notsent_bytes", "By", "Bytes written but not yet sent (TCP_INFO notsent_bytes)")
+	m.hTCPDelivery = b.hist("tcp_delivery_rate", "By/s", "TCP delivery rate estimate (TCP_INFO delivery_rate)")
+	m.hTCPPacing = b.hist("tcp_pacing_rate", "By/s", "TCP pacing rate (TCP_INFO pacing_rate)")
+
+	m.cTCPSamples = b.counter("tcp_samples", "{sample}", "TCP_INFO samples taken, by trigger")
+	m.cTCPSamplesDropped = b.counter("tcp_samples_dropped", "{sample}", "TCP_INFO sample requests dropped, by trigger and reason (queue_full, rate_limited, cooldown)")
+
+	m.cSlowCallLogsSuppressed = b.counter("slow_call_logs_suppressed", "{call}", "Slow calls not logged, by reason (sampled, rate_limited)")
+	m.cCallRecordsDropped = b.counter("call_records_dropped", "{call}", "Call records dropped because the CallObserver queue was full")
+
+	m.cTCPBusy = b.floatCounter("tcp_busy_time_ms", "ms", "Time the connection had data in flight (TCP_INFO busy_time)")
+	m.cTCPRwndLimited = b.floatCounter("tcp_rwnd_limited_ms", "ms", "Time sending was limited by the peer's receive window (TCP_INFO rwnd_limited)")
+	m.cTCPSndbufLimited = b.floatCounter("tcp_sndbuf_limited_ms", "ms", "Time sending was limited by the local send buffer (TCP_INFO sndbuf_limited)")
+
+	m.hTCPConnect = b.hist("tcp_connect_ms", "ms", "Time to establish a TCP connection (the dialer call)")
+	m.hTLSHandshake = b.hist("tls_handshake_ms", "ms", "Time of the client TLS handshake")
+	m.cConnectFailures = b.counter("tcp_connect_failures", "{connection}", "Failed TCP connection attempts, by cause")
+	m.cTLSFailures = b.counter("tls_handshake_failures", "{handshake}", "Failed TLS handshakes, by cause")
+
+	m.cConnsOpened = b.counter("connections_opened", "{connection}", "HTTP/2 connections opened")
+	m.cConnsClosed = b.counter("connections_closed", "{connection}", "HTTP/2 connections closed")
+	m.udConnsActive = b.upDownCounter("connections_active", "{connection}", "HTTP/2 connections currently open")
+	m.hConnLifetime = b.hist("connection_lifetime_s", "s", "Time from TCP connect to HTTP/2 connection close")
+
+	m.hConnMinRTT = b.hist("conn_min_rtt_ms", "ms", "Lowest TCP RTT over a closed connection's life (TCP_INFO min_rtt)")
+	m.hConnTotalRetrans = b.hist("conn_total_retrans", "{segment}", "TCP segments retransmitted over a closed connection's life")
+	m.hConnBytesSent = b.hist("conn_bytes_sent", "By", "Bytes sent over a closed connection's life, including retransmissions")
+	m.hConnBytesAcked = b.hist("conn_bytes_acked", "By", "Bytes acknowledged by the peer over a closed connection's life")
+
+	m.hResolverLatency = b.hist("resolver_latency_ms", "ms", "Time from a resolution request (initial or ResolveNow) to the resolver's result")
+	m.cResolverErrors = b.counter("resolver_errors", "{error}", "Resolution errors reported by the resolver")
+	m.gResolverAddresses = b.gauge("resolver_addresses", "{address}", "Number of addresses in the latest resolver update")
+	m.cResolverAdded = b.counter("resolver_addresses_added", "{address}", "Addresses added by resolver updates")
+	m.cResolverRemoved = b.counter("resolver_addresses_removed", "{address}", "Addresses removed by resolver updates")
+
+	if b.err != nil {
+		return nil, b.err
+	}
 
-	m.callCache.m = make(map[callAttrKey]metric.RecordOption)
+	m.callCache.m = make(map[callAttrKey]metric.MeasurementOption)
 	m.callCache.max = maxAttrCacheSize
 
-	m.tcpCache.m = make(map[string]metric.RecordOption)
+	m.attemptCache.m = make(map[attemptAttrKey]metric.MeasurementOption)
+	m.attemptCache.max = maxAttrCacheSize
+	m.tcpCache.m = make(map[tcpAttrKey]metric.MeasurementOption)
 	m.tcpCache.max = maxAttrCacheSize
 
-	return m
-}
-
-func mustHist(m metric.Meter, name string) metric.Float64Histogram {
-	h, _ := m.Float64Histogram(name)
-	return h
+	return m, nil
 }
 
 func (m *metrics) recordCall(ctx context.Context, method string, st *callState,
-	total, streamEstablish, sendStall, responseWait time.Duration,
-	attempts uint32,
+	code codes.Code, errClass string, p callPhases,
 ) {
 	if ctx == nil {
 		ctx = context.Background()
 	}
 
 	remoteIP := st.getRemoteIP()
-	opt := m.callRecordOption(method, remoteIP)
+	opt := m.callRecordOption(method, remoteIP, code, errClass)
+
+	m.cCalls.Add(ctx, 1, opt)
+	m.hTotal.Record(ctx, durMs(p.total), opt)
+	m.hStreamEstablish.Record(ctx, durMs(p.streamEstablish), opt)
+	m.hSendStall.Record(ctx, durMs(p.sendStall), opt)
+	m.hResponseWait.Record(ctx, durMs(p.responseWait), opt)
+	m.hAttempts.Record(ctx, float64(p.attempts), opt)
+
+	// Only calls that went through rgrpc's balancer wrapper have a pick time.
+	if p.pickWait > 0 {
+		m.hPickWait.Record(ctx, durMs(p.pickWait), opt)
+	}
+	if p.pickBlocked {
+		m.cPicksBlocked.Add(ctx, 1, opt)
+	}
+}
+
+func (m *metrics) recordAttempt(ctx context.Context, method, remoteIP string, code codes.Code, attemptType string, p attemptPhases) {
+	opt := m.attemptRecordOption(method, remoteIP, code, attemptType)
+	m.hAttemptDuration.Record(ctx, durMs(p.total), opt)
+	m.hAttemptStreamEstablish.Record(ctx, durMs(p.streamEstablish), opt)
+	m.hAttemptResponseWait.Record(ctx, durMs(p.responseWait), opt)
+}
+
+func (m *metrics) recordRetry(ctx context.Context, method string, code codes.Code) {
+	m.cRetries.Add(ctx, 1, m.connOption(
+		attribute.String("method", method),
+		attribute.String("grpc_code", grpcCodeName(code)),
+	))
+}
 
-	m.hTotal.Record(ctx, durMs(total), opt)
-	m.hStreamEstablish.Record(ctx, durMs(streamEstablish), opt)
-	m.hSendStall.Record(ctx, durMs(sendStall), opt)
-	m.hResponseWait.Record(ctx, durMs(responseWait), opt)
-	m.hAttempts.Record(ctx, float64(attempts), opt)
+func (m *metrics) recordRetryThrottled(ctx context.Context, method string) {
+	m.cRetriesThrottled.Add(ctx, 1, m.connOption(attribute.String("method", method)))
+}
+
+// Hedging events, one counter each.
+const (
+	hedgeSent = iota
+	hedgeWon
+	hedgeWasted
+	hedgeThrottled
+)
+
+func (m *metrics) recordHedge(ctx context.Context, method string, event int) {
+	var c metric.Int64Counter
+	switch event {
+	case hedgeSent:
+		c = m.cHedgesSent
+	case hedgeWon:
+		c = m.cHedgesWon
+	case hedgeWasted:
+		c = m.cHedgesWasted
+	default:
+		c = m.cHedgesThrottled
+	}
+	c.Add(ctx, 1, m.connOption(attribute.String("method", method)))
+}
+
+func (m *metrics) recordBreakerTransition(ctx context.Context, remoteIP string, state int32) {
+	m.gBreakerState.Record(ctx, int64(state), m.connOption(attribute.String("remote_ip", remoteIP)))
+	m.cBreakerTransitions.Add(ctx, 1, m.connOption(
+		attribute.String("remote_ip", remoteIP),
+		attribute.String("state", breakerStateNames[state]),
+	))
 }
 
-func (m *metrics) recordTCP(ctx context.Context, remoteIP string, tcp TCPInfoSummary, retransDelta uint32) {
+func (m *metrics) recordOutlierEjection(ctx context.Context, remoteIP, reason string) {
+	m.cOutlierEjections.Add(ctx, 1, m.connOption(
+		attribute.String("remote_ip", remoteIP),
+		attribute.String("reason", reason),
+	))
+}
+
+func (m *metrics) recordOutliersEjected(ctx context.Context, n int) {
+	m.gOutliersEjected.Record(ctx, int64(n), m.connOption())
+}
+
+func (m *metrics) recordTCP(ctx context.Context, remoteIP, trigger string, tcp TCPInfoSummary, d tcpDeltas) {
 	if ctx == nil {
 		ctx = context.Background()
 	}
-	opt := m.tcpRecordOption(remoteIP)
+	opt := m.tcpRecordOption(remoteIP, trigger)
 
 	if tcp.Available {
 		m.hTCPRttMs.Record(ctx, durMs(tcp.RTT), opt)
 		m.hTCPCwnd.Record(ctx, float64(tcp.SndCwnd), opt)
-		m.hTCPRetransDelta.Record(ctx, float64(retransDelta), opt)
+		m.hTCPRetransDelta.Record(ctx, float64(d.retrans), opt)
+		m.hTCPRttVar.Record(ctx, durMs(tcp.RTTVar), opt)
+		m.hTCPMinRTT.Record(ctx, durMs(tcp.MinRTT), opt)
+		if tcp.SndSsthresh < tcpInfiniteSsthresh {
+			m.hTCPSsthresh.Record(ctx, float64(tcp.SndSsthresh), opt)
+		}
+		m.hTCPUnacked.Record(ctx, float64(tcp.Unacked), opt)
+		m.hTCPLost.Record(ctx, float64(tcp.Lost), opt)
+		m.hTCPRetrans.Record(ctx, float64(tcp.Retrans), opt)
+		m.hTCPReordering.Record(ctx, float64(tcp.Reordering), opt)
+		m.hTCPRcvSpace.Record(ctx, float64(tcp.RcvSpace), opt)
+		m.hTCPNotsent.Record(ctx, float64(tcp.NotsentBytes), opt)
+		m.hTCPDelivery.Record(ctx, float64(tcp.DeliveryRate), opt)
+		if tcp.PacingRate != 0 && tcp.PacingRate != tcpUnlimitedPacing {
+			m.hTCPPacing.Record(ctx, float64(tcp.PacingRate), opt)
+		}
+		m.recordTCPLimited(ctx, opt, d)
+	}
+}
+
+// Connection-level events are rare (once per connection), so their attributes
+// are built per event instead of going through the bounded caches.
+
+func (m *metrics) recordTCPSample(ctx context.Context, trigger string) {
+	m.cTCPSamples.Add(ctx, 1, m.connOption(attribute.String("trigger", trigger)))
+}
+
+func (m *metrics) recordTCPSampleDropped(ctx context.Context, trigger, reason string) {
+	m.cTCPSamplesDropped.Add(ctx, 1, m.connOption(
+		attribute.String("trigger", trigger),
+		attribute.String("reason", reason),
+	))
+}
+
+func (m *metrics) recordSlowCallLogSuppressed(ctx context.Context, reason string) {
+	m.cSlowCallLogsSuppressed.Add(ctx, 1, m.connOption(attribute.String("reason", reason)))
+}
+
+func (m *metrics) recordCallRecordDropped(ctx context.Context) {
+	m.cCallRecordsDropped.Add(ctx, 1, m.connOption())
+}
+
+// tcpInfiniteSsthresh is TCP_INFINITE_SSTHRESH: no loss seen yet, still in slow start.
+const tcpInfiniteSsthresh = 0x7fffffff
+
+// tcpUnlimitedPacing is the pacing_rate reported when the socket is not paced.
+const tcpUnlimitedPacing = ^uint64(0)
+
+func (m *metrics) recordTCPLimited(ctx context.Context, opt metric.MeasurementOption, d tcpDeltas) {
+	m.cTCPBusy.Add(ctx, durMs(d.busy), opt)
+	m.cTCPRwndLimited.Add(ctx, durMs(d.rwndLimited), opt)
+	m.cTCPSndbufLimited.Add(ctx, durMs(d.sndbufLimited), opt)
+}
+
+func (m *metrics) recordConnect(ctx context.Context, remoteIP string, d time.Duration) {
+	m.hTCPConnect.Record(ctx, durMs(d), m.connOption(attribute.String("remote_ip", remoteIP)))
+}
+
+func (m *metrics) recordConnectFailure(ctx context.Context, remoteIP, cause string) {
+	m.cConnectFailures.Add(ctx, 1, m.connOption(
+		attribute.String("remote_ip", remoteIP),
+		attribute.String("cause", cause),
+	))
+}
+
+func (m *metrics) recordTLSHandshake(ctx context.Context, remoteIP string, cs *tls.ConnectionState, d time.Duration) {
+	m.hTLSHandshake.Record(ctx, durMs(d), m.connOption(
+		attribute.String("remote_ip", remoteIP),
+		attribute.String("tls_version", tls.VersionName(cs.Version)),
+		attribute.String("tls_cipher", tls.CipherSuiteName(cs.CipherSuite)),
+		attribute.String("alpn", cs.NegotiatedProtocol),
+	))
+}
+
+func (m *metrics) recordTLSFailure(ctx context.Context, remoteIP, cause string) {
+	m.cTLSFailures.Add(ctx, 1, m.connOption(
+		attribute.String("remote_ip", remoteIP),
+		attribute.String("cause", cause),
+	))
+}
+
+func (m *metrics) recordConnBegin(ctx context.Context, remoteIP string) {
+	opt := m.connOption(attribute.String("remote_ip", remoteIP))
+	m.cConnsOpened.Add(ctx, 1, opt)
+	m.udConnsActive.Add(ctx, 1, opt)
+}
+
+func (m *metrics) recordConnEnd(ctx context.Context, remoteIP string, lifetime time.Duration) {
+	opt := m.connOption(attribute.String("remote_ip", remoteIP))
+	m.cConnsClosed.Add(ctx, 1, opt)
+	m.udConnsActive.Add(ctx, -1, opt)
+	m.hConnLifetime.Record(ctx, lifetime.Seconds(), opt)
+}
+
+// recordConnSummary records the final TCP_INFO snapshot of a closed connection.
+// Nothing is recorded when the snapshot is unavailable (non-Linux, non-TCP).
+// The limited-time deltas since the last periodic sample are added to the
+// counters so their totals also cover short-lived connections.
+func (m *metrics) recordConnSummary(ctx context.Context, sum ConnectionSummary, d tcpDeltas) {
+	if !sum.TCP.Available {
+		return
 	}
+	opt := m.tcpRecordOption(sum.RemoteIP, triggerClose)
+	m.recordTCPLimited(ctx, opt, d)
+	m.hConnMinRTT.Record(ctx, durMs(sum.TCP.MinRTT), opt)
+	m.hConnTotalRetrans.Record(ctx, float64(sum.TCP.TotalRetrans), opt)
+	m.hConnBytesSent.Record(ctx, float64(sum.TCP.BytesSent), opt)
+	m.hConnBytesAcked.Record(ctx, float64(sum.TCP.BytesAcked), opt)
+}
+
+func (m *metrics) recordResolverLatency(ctx context.Context, scheme, target string, d time.Duration) {
+	m.hResolverLatency.Record(ctx, durMs(d), m.resolverOption(scheme, target))
+}
+
+func (m *metrics) recordResolverError(ctx context.Context, scheme, target string) {
+	m.cResolverErrors.Add(ctx, 1, m.resolverOption(scheme, target))
 }
 
-func (m *metrics) callRecordOption(method, remoteIP string) metric.RecordOption {
-	key := callAttrKey{method: method, remoteIP: remoteIP}
+func (m *metrics) recordResolverUpdate(ctx context.Context, scheme, target string, addrs, added, removed int) {
+	opt := m.resolverOption(scheme, target)
+	m.gResolverAddresses.Record(ctx, int64(addrs), opt)
+	m.cResolverAdded.Add(ctx, int64(added), opt)
+	m.cResolverRemoved.Add(ctx, int64(removed), opt)
+}
+
+func (m *metrics) resolverOption(scheme, target string) metric.MeasurementOption {
+	return m.connOption(
+		attribute.String("scheme", scheme),
+		attribute.String("target", target),
+	)
+}
+
+func (m *metrics) connOption(attrs ...attribute.KeyValue) metric.MeasurementOption {
+	return metric.WithAttributes(append(attrs, m.cfg.Labels...)...)
+}
+
+func (m *metrics) callRecordOption(method, remoteIP string, code codes.Code, errClass string) metric.MeasurementOption {
+	key := callAttrKey{method: method, remoteIP: remoteIP, code: code, errClass: errClass}
 
 	m.callCache.mu.Lock()
 	defer m.callCache.mu.Unlock()
@@ -124,35 +496,68 @@ func (m *metrics) callRecordOption(method, remoteIP string) metric.RecordOption
 
 	// bound cache size (simple strategy: clear when too big)
 	if len(m.callCache.m) >= m.callCache.max {
-		m.callCache.m = make(map[callAttrKey]metric.RecordOption)
+		m.callCache.m = make(map[callAttrKey]metric.MeasurementOption)
 	}
 
 	attrs := []attribute.KeyValue{
 		attribute.String("method", method),
 		attribute.String("remote_ip", remoteIP),
+		attribute.String("grpc_code", grpcCodeName(code)),
+		attribute.String("error_class", errClass),
 	}
+	attrs = append(attrs, m.cfg.Labels...)
 	opt := metric.WithAttributes(attrs...)
 	m.callCache.m[key] = opt
 	return opt
 }
 
-func (m *metrics) tcpRecordOption(remoteIP string) metric.RecordOption {
+func (m *metrics) attemptRecordOption(method, remoteIP string, code codes.Code, attemptType string) metric.MeasurementOption {
+	key := attemptAttrKey{method: method, remoteIP: remoteIP, code: code, attemptType: attemptType}
+
+	m.attemptCache.mu.Lock()
+	defer m.attemptCache.mu.Unlock()
+
+	if opt, ok := m.attemptCache.m[key]; ok {
+		return opt
+	}
+
+	if len(m.attemptCache.m) >= m.attemptCache.max {
+		m.attemptCache.m = make(map[attemptAttrKey]metric.MeasurementOption)
+	}
+
+	attrs := []attribute.KeyValue{
+		attribute.String("method", method),
+		attribute.String("remote_ip", remoteIP),
+		attribute.String("grpc_code", grpcCodeName(code)),
+		attribute.String("attempt_type", attemptType),
+	}
+	attrs = append(attrs, m.cfg.Labels...)
+	opt := metric.WithAttributes(attrs...)
+	m.attemptCache.m[key] = opt
+	return opt
+}
+
+func (m *metrics) tcpRecordOption(remoteIP, trigger string) metric.MeasurementOption {
+	key := tcpAttrKey{remoteIP: remoteIP, trigger: trigger}
+
 	m.tcpCache.mu.Lock()
 	defer m.tcpCache.mu.Unlock()
 
-	if opt, ok := m.tcpCache.m[remoteIP]; ok {
+	if opt, ok := m.tcpCache.m[key]; ok {
 		return opt
 	}
 
 	if len(m.tcpCache.m) >= m.tcpCache.max {
-		m.tcpCache.m = make(map[string]metric.RecordOption)
+		m.tcpCache.m = make(map[tcpAttrKey]metric.MeasurementOption)
 	}
 
 	attrs := []attribute.KeyValue{
 		attribute.String("remote_ip", remoteIP),
+		attribute.String("trigger", trigger),
 	}
+	attrs = append(attrs, m.cfg.Labels...)
 	opt := metric.WithAttributes(attrs...)
-	m.tcpCache.m[remoteIP] = opt
+	m.tcpCache.m[key] = opt
 	return opt
 }
 
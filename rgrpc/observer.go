@@ -0,0 +1,114 @@
+package rgrpc
+
+import (
+	"context"
+	"time"
+
+	"google.golang.org/grpc/codes"
+)
+
+// CallObserver receives a CallRecord for every finished call of a client.
+//
+// ObserveCall is not called on the RPC's goroutine: records are queued and
+// delivered in order by one goroutine per client, so a slow observer delays
+// later records but never the calls themselves. When the queue is full, records
+// are dropped and counted in call_records_dropped.
+type CallObserver interface {
+	ObserveCall(CallRecord)
+}
+
+// CallObserverFunc adapts a function to CallObserver.
+type CallObserverFunc func(CallRecord)
+
+// ObserveCall calls f(r).
+func (f CallObserverFunc) ObserveCall(r CallRecord) { f(r) }
+
+const defaultCallObserverQueueSize = 1024
+
+// newCallRecord builds the public record of a finished call.
+func newCallRecord(target string, st *callState, code codes.Code, errClass string, callErr error, p callPhases) CallRecord {
+	r := CallRecord{
+		Method:          st.method,
+		Target:          target,
+		RemoteIP:        st.getRemoteIP(),
+		Start:           time.Unix(0, st.startUnix),
+		Streaming:       st.isStreaming,
+		Total:           p.total,
+		PickWait:        p.pickWait,
+		StreamEstablish: p.streamEstablish,
+		SendStall:       p.sendStall,
+		ResponseWait:    p.responseWait,
+		PickBlocked:     p.pickBlocked,
+		Attempts:        int(p.attempts),
+		Code:            code,
+		ErrorClass:      errClass,
+		Err:             callErr,
+		BytesSent:       st.bytesSent.Load(),
+		BytesReceived:   st.bytesReceived.Load(),
+	}
+	if ra := st.remoteTCP.Load(); ra != nil {
+		r.RemoteAddr = ra.String()
+	}
+	if la := st.localTCP.Load(); la != nil {
+		r.LocalAddr = la.String()
+	}
+	if st.span != nil {
+		if sc := st.span.SpanContext(); sc.HasTraceID() {
+			r.TraceID = sc.TraceID().String()
+		}
+	}
+	return r
+}
+
+// callDispatcher hands call records to the CallObserver from a bounded queue.
+type callDispatcher struct {
+	obs CallObserver
+	met *metrics
+	ch  chan CallRecord
+}
+
+// newCallDispatcher returns nil when no observer is configured. The dispatcher
+// delivers what is already queued and exits once stop is closed.
+func newCallDispatcher(cfg Config, met *metrics, stop <-chan struct{}) *callDispatcher {
+	if cfg.CallObserver == nil {
+		return nil
+	}
+	size := cfg.CallObserverQueueSize
+	if size == 0 {
+		size = defaultCallObserverQueueSize
+	}
+	d := &callDispatcher{
+		obs: cfg.CallObserver,
+		met: met,
+		ch:  make(chan CallRecord, size),
+	}
+	go d.loop(stop)
+	return d
+}
+
+// offer queues r without blocking.
+func (d *callDispatcher) offer(ctx context.Context, r CallRecord) {
+	select {
+	case d.ch <- r:
+	default:
+		d.met.recordCallRecordDropped(ctx)
+	}
+}
+
+func (d *callDispatcher) loop(stop <-chan struct{}) {
+	for {
+		select {
+		case r := <-d.ch:
+			d.obs.ObserveCall(r)
+		case <-stop:
+			for {
+				select {
+				case r := <-d.ch:
+					d.obs.ObserveCall(r)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package rgrpc
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// TestCallObserver verifies that the observer receives a complete record of a
+// real call.
+func TestCallObserver(t *testing.T) {
+	addr := startHealthServer(t, nil)
+	records := make(chan CallRecord, 1)
+	target := "passthrough:///" + addr
+	cc, err := New(context.Background(), target,
+		WithTCPSampling(0),
+		WithCallObserver(CallObserverFunc(func(r CallRecord) { records <- r })),
+		WithDialOptions(grpc.WithTransportCredentials(insecure.NewCredentials())),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cc.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if _, err := healthpb.NewHealthClient(cc).Check(ctx, &healthpb.HealthCheckRequest{}); err != nil {
+		t.Fatal(err)
+	}
+
+	var r CallRecord
+	select {
+	case r = <-records:
+	case <-ctx.Done():
+		t.Fatal("observer not called")
+	}
+	if r.Method != "/grpc.health.v1.Health/Check" || r.Target != target || r.Streaming {
+		t.Errorf("unexpected record %+v", r)
+	}
+	if r.RemoteAddr != addr || r.LocalAddr == "" || r.RemoteIP != "127.0.0.1" {
+		t.Errorf("addresses: remote %q local %q ip %q", r.RemoteAddr, r.LocalAddr, r.RemoteIP)
+	}
+	if r.Code != codes.OK || r.ErrorClass != errorClassOK || r.Err != nil || r.Attempts != 1 {
+		t.Errorf("status: code %v class %s err %v attempts %d", r.Code, r.ErrorClass, r.Err, r.Attempts)
+	}
+	if r.Total <= 0 || r.ResponseWait <= 0 || r.ResponseWait > r.Total || r.Start.IsZero() {
+		t.Errorf("phases: %+v", r)
+	}
+	if r.BytesSent <= 0 || r.BytesReceived <= 0 {
+		t.Errorf("bytes: sent %d received %d", r.BytesSent, r.BytesReceived)
+	}
+}
+
+// TestCallObserverQueueFull verifies that a blocked observer never blocks
+// calls: records beyond the queue are dropped and counted.
+func TestCallObserverQueueFull(t *testing.T) {
+	release := make(chan struct{})
+	reader := sdkmetric.NewManualReader()
+	cfg := DefaultConfig()
+	cfg.TCPMetricsInterval = 0
+	cfg.MeterProvider = sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+	cfg.CallObserver = CallObserverFunc(func(CallRecord) { <-release })
+	cfg.CallObserverQueueSize = 1
+	h := mustNewHooks(t, cfg)
+	defer h.close()
+	defer close(release)
+
+	for i := 0; i < 5; i++ {
+		emitUnaryCall(t, h, "/svc/Method")
+	}
+	// At most one record is being observed and one is queued.
+	if got := int64Value(t, reader, "rgrpc.call_records_dropped"); got < 3 {
+		t.Errorf("call_records_dropped = %d, want >= 3", got)
+	}
+}
@@ -0,0 +1,303 @@
+package rgrpc
+
+import (
+	"context"
+	"log/slog"
+	"net"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/resolver"
+)
+
+// Option configures a client created with New.
+//
+// Options are applied in order on top of DefaultConfig(); later options win.
+// Unlike NewClient, New never reads the process-wide SetDefaultConfig value,
+// so independent libraries in the same binary can use different settings.
+type Option func(*clientOptions)
+
+type clientOptions struct {
+	cfg      Config
+	dialOpts []grpc.DialOption
+}
+
+// WithConfig replaces the whole configuration with cfg. Options applied after
+// it still override individual fields. Useful when migrating from Config.
+func WithConfig(cfg Config) Option {
+	return func(o *clientOptions) {
+		o.cfg = cfg
+	}
+}
+
+// WithMetricPrefix sets the prefix for all emitted metric names (see Config.MetricPrefix).
+func WithMetricPrefix(prefix string) Option {
+	return func(o *clientOptions) {
+		o.cfg.MetricPrefix = prefix
+	}
+}
+
+// WithTCPSampling sets how often TCP metrics are sampled for active connections.
+// An interval of 0 disables periodic sampling (see Config.TCPMetricsInterval).
+func WithTCPSampling(interval time.Duration) Option {
+	return func(o *clientOptions) {
+		o.cfg.TCPMetricsInterval = interval
+	}
+}
+
+// WithClientSideLB enables or disables client-side round-robin load balancing
+// (see Config.EnableClientSideLB).
+func WithClientSideLB(enabled bool) Option {
+	return func(o *clientOptions) {
+		o.cfg.EnableClientSideLB = enabled
+	}
+}
+
+// WithLoadBalancingPolicy selects the client-side load balancing policy
+// (LoadBalancingRoundRobin or LoadBalancingP2CEWMA) and enables client-side
+// load balancing (see Config.LoadBalancingPolicy).
+func WithLoadBalancingPolicy(policy string) Option {
+	return func(o *clientOptions) {
+		o.cfg.EnableClientSideLB = true
+		o.cfg.LoadBalancingPolicy = policy
+	}
+}
+
+// WithLabels adds constant attributes to every metric emitted by the client
+// (see Config.Labels). Calling it multiple times appends.
+func WithLabels(labels ...attribute.KeyValue) Option {
+	return func(o *clientOptions) {
+		o.cfg.Labels = append(append([]attribute.KeyValue(nil), o.cfg.Labels...), labels...)
+	}
+}
+
+// WithDialer makes the client establish connections with dial while rgrpc
+// keeps tracking them. Use it instead of grpc.WithContextDialer.
+// See Config.Dialer.
+func WithDialer(dial func(ctx context.Context, addr string) (net.Conn, error)) Option {
+	return func(o *clientOptions) {
+		o.cfg.Dialer = dial
+	}
+}
+
+// WithTransportCredentials installs creds for the client with handshake timing.
+// Use it instead of grpc.WithTransportCredentials. See Config.TransportCredentials.
+func WithTransportCredentials(creds credentials.TransportCredentials) Option {
+	return func(o *clientOptions) {
+		o.cfg.TransportCredentials = creds
+	}
+}
+
+// WithResolverMetrics enables or disables the resolver wrapper
+// (see Config.EnableResolverMetrics).
+func WithResolverMetrics(enabled bool) Option {
+	return func(o *clientOptions) {
+		o.cfg.EnableResolverMetrics = enabled
+	}
+}
+
+// WithResolver installs a custom resolver for the client, measured like the
+// built-in ones. Use it instead of grpc.WithResolvers. See Config.Resolver.
+func WithResolver(b resolver.Builder) Option {
+	return func(o *clientOptions) {
+		o.cfg.Resolver = b
+	}
+}
+
+// WithPerConnectionTCPMetrics reports the latest TCP_INFO of each live
+// connection as gauges keyed by conn_id. See Config.PerConnectionTCPMetrics.
+func WithPerConnectionTCPMetrics() Option {
+	return func(o *clientOptions) {
+		o.cfg.PerConnectionTCPMetrics = true
+	}
+}
+
+// WithTCPSampler sets the TCP_INFO sampler's rate limit, burst, per-connection
+// cooldown and queue size. See Config.TCPSampler.
+func WithTCPSampler(s TCPSamplerConfig) Option {
+	return func(o *clientOptions) {
+		o.cfg.TCPSampler = s
+	}
+}
+
+// WithSlowCallThreshold samples TCP_INFO of the connection of every call slower
+// than d. See Config.SlowCall.
+func WithSlowCallThreshold(d time.Duration) Option {
+	return func(o *clientOptions) {
+		o.cfg.SlowCall.Threshold = d
+	}
+}
+
+// WithSlowCallPercentile samples TCP_INFO of the connection of calls slower than
+// the rolling p-th percentile (e.g. 0.99) of their method. See Config.SlowCall.
+func WithSlowCallPercentile(p float64) Option {
+	return func(o *clientOptions) {
+		o.cfg.SlowCall.Percentile = p
+	}
+}
+
+// WithSlowCallMethodThreshold sets the absolute slow-call threshold of one
+// method ("/pkg.Service/Method"), overriding WithSlowCallThreshold for it.
+// See Config.SlowCall.
+func WithSlowCallMethodThreshold(method string, d time.Duration) Option {
+	return func(o *clientOptions) {
+		m := make(map[string]time.Duration, len(o.cfg.SlowCall.MethodThresholds)+1)
+		for k, v := range o.cfg.SlowCall.MethodThresholds {
+			m[k] = v
+		}
+		m[method] = d
+		o.cfg.SlowCall.MethodThresholds = m
+	}
+}
+
+// WithSlowCallLogger logs one record per slow call to l, with the default
+// sampling and rate limit. Slow calls are defined by the WithSlowCall* options.
+// See Config.SlowCallLog.
+func WithSlowCallLogger(l *slog.Logger) Option {
+	return func(o *clientOptions) {
+		o.cfg.SlowCallLog.Logger = l
+	}
+}
+
+// WithConnectionClosedCallback registers fn to receive a ConnectionSummary for
+// every tracked connection that closes. See Config.OnConnectionClosed.
+func WithConnectionClosedCallback(fn func(ConnectionSummary)) Option {
+	return func(o *clientOptions) {
+		o.cfg.OnConnectionClosed = fn
+	}
+}
+
+// WithRetryPolicy retries failed unary calls according to p. See Config.Retry.
+func WithRetryPolicy(p RetryPolicy) Option {
+	return func(o *clientOptions) {
+		o.cfg.Retry.Policy = p
+	}
+}
+
+// WithMethodRetryPolicy sets the retry policy of one method
+// ("/pkg.Service/Method") or service ("/pkg.Service/"), overriding
+// WithRetryPolicy for it. See Config.Retry.
+func WithMethodRetryPolicy(method string, p RetryPolicy) Option {
+	return func(o *clientOptions) {
+		m := make(map[string]RetryPolicy, len(o.cfg.Retry.MethodPolicies)+1)
+		for k, v := range o.cfg.Retry.MethodPolicies {
+			m[k] = v
+		}
+		m[method] = p
+		o.cfg.Retry.MethodPolicies = m
+	}
+}
+
+// WithRetryBudget caps retries as a fraction of calls. See RetryBudget.
+func WithRetryBudget(b RetryBudget) Option {
+	return func(o *clientOptions) {
+		o.cfg.Retry.Budget = b
+	}
+}
+
+// WithAttemptMetrics enables or disables the attempt_* histograms for clients
+// without retries or hedging (see Config.EnableAttemptMetrics).
+func WithAttemptMetrics(enabled bool) Option {
+	return func(o *clientOptions) {
+		o.cfg.EnableAttemptMetrics = enabled
+	}
+}
+
+// WithHedging enables request hedging for the given full methods
+// ("/pkg.Service/Method") or services ("/pkg.Service/"). See Config.Hedging
+// for the delay and budget fields, which keep their current values.
+func WithHedging(methods ...string) Option {
+	return func(o *clientOptions) {
+		o.cfg.Hedging.Methods = append(o.cfg.Hedging.Methods, methods...)
+	}
+}
+
+// WithCircuitBreaker enables per-backend circuit breakers configured by cb
+// (see Config.CircuitBreaker); cb.Enabled need not be set.
+func WithCircuitBreaker(cb CircuitBreakerConfig) Option {
+	return func(o *clientOptions) {
+		cb.Enabled = true
+		o.cfg.CircuitBreaker = cb
+	}
+}
+
+// WithOutlierDetection enables outlier detection configured by od (see
+// Config.OutlierDetection); od.Enabled need not be set.
+func WithOutlierDetection(od OutlierDetectionConfig) Option {
+	return func(o *clientOptions) {
+		od.Enabled = true
+		o.cfg.OutlierDetection = od
+	}
+}
+
+// WithCallObserver registers obs to receive a CallRecord for every finished
+// call. See Config.CallObserver.
+func WithCallObserver(obs CallObserver) Option {
+	return func(o *clientOptions) {
+		o.cfg.CallObserver = obs
+	}
+}
+
+// WithCallHistory enables or disables the slowest and recent failed call
+// lists shown by DebugHandler (see Config.EnableCallHistory).
+func WithCallHistory(enabled bool) Option {
+	return func(o *clientOptions) {
+		o.cfg.EnableCallHistory = enabled
+	}
+}
+
+// WithHistogramBuckets overrides the bucket boundaries of one histogram,
+// named without the prefix (e.g. "call_total_ms"). See Config.HistogramBuckets.
+func WithHistogramBuckets(name string, bounds ...float64) Option {
+	return func(o *clientOptions) {
+		m := make(map[string][]float64, len(o.cfg.HistogramBuckets)+1)
+		for k, v := range o.cfg.HistogramBuckets {
+			m[k] = v
+		}
+		m[name] = bounds
+		o.cfg.HistogramBuckets = m
+	}
+}
+
+// WithMeterProvider routes this client's metrics to mp instead of the global
+// provider (see Config.MeterProvider).
+func WithMeterProvider(mp metric.MeterProvider) Option {
+	return func(o *clientOptions) {
+		o.cfg.MeterProvider = mp
+	}
+}
+
+// WithTracerProvider routes this client's spans to tp instead of the global
+// provider (see Config.TracerProvider).
+func WithTracerProvider(tp trace.TracerProvider) Option {
+	return func(o *clientOptions) {
+		o.cfg.TracerProvider = tp
+	}
+}
+
+// WithTracing enables per-RPC client spans (see Config.EnableTracing).
+func WithTracing() Option {
+	return func(o *clientOptions) {
+		o.cfg.EnableTracing = true
+	}
+}
+
+// WithBlockingWarmup makes New connect eagerly and wait until the connection
+// is READY or the context passed to New is done (see Config.BlockingWarmup).
+func WithBlockingWarmup() Option {
+	return func(o *clientOptions) {
+		o.cfg.BlockingWarmup = true
+	}
+}
+
+// WithDialOptions passes additional options to grpc.NewClient.
+// Calling it multiple times appends.
+func WithDialOptions(opts ...grpc.DialOption) Option {
+	return func(o *clientOptions) {
+		o.dialOpts = append(o.dialOpts, opts...)
+	}
+}
@@ -0,0 +1,160 @@
+package rgrpc
+
+import (
+	"context"
+	"log/slog"
+	"net"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// TestOptionsApplyOnTopOfDefaults verifies that options start from DefaultConfig
+// and ignore the process-wide SetDefaultConfig value.
+func TestOptionsApplyOnTopOfDefaults(t *testing.T) {
+	global := DefaultConfig()
+	global.MetricPrefix = "global"
+	SetDefaultConfig(global)
+	defer SetDefaultConfig(DefaultConfig())
+
+	o := clientOptions{cfg: DefaultConfig()}
+	for _, opt := range []Option{
+		WithMetricPrefix("lib"),
+		WithTCPSampling(0),
+		WithLabels(attribute.String("upstream", "a")),
+		WithLabels(attribute.String("team", "b")),
+	} {
+		opt(&o)
+	}
+
+	if o.cfg.MetricPrefix != "lib" {
+		t.Errorf("MetricPrefix = %q, want lib", o.cfg.MetricPrefix)
+	}
+	if o.cfg.TCPMetricsInterval != 0 {
+		t.Errorf("TCPMetricsInterval = %v, want 0", o.cfg.TCPMetricsInterval)
+	}
+	if len(o.cfg.Labels) != 2 {
+		t.Errorf("Labels = %v, want 2 entries", o.cfg.Labels)
+	}
+}
+
+func TestReservedLabelRejected(t *testing.T) {
+	_, err := New(context.Background(), "passthrough:///localhost:1",
+		WithLabels(attribute.String("method", "x")),
+	)
+	if err == nil {
+		t.Fatal("expected error for reserved label")
+	}
+}
+
+// TestBlockingWarmup verifies that warm-up waits for READY and honors ctx.
+func TestBlockingWarmup(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := grpc.NewServer()
+	go srv.Serve(lis)
+	defer srv.Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	cc, err := New(ctx, "passthrough:///"+lis.Addr().String(),
+		WithTCPSampling(0),
+		WithBlockingWarmup(),
+		WithDialOptions(grpc.WithTransportCredentials(insecure.NewCredentials())),
+	)
+	if err != nil {
+		t.Fatalf("warm-up against live server: %v", err)
+	}
+	cc.Close()
+
+	// Nothing listens on a closed listener's port.
+	dead, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := dead.Addr().String()
+	dead.Close()
+
+	ctx2, cancel2 := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel2()
+	_, err = New(ctx2, "passthrough:///"+addr,
+		WithTCPSampling(0),
+		WithBlockingWarmup(),
+		WithDialOptions(grpc.WithTransportCredentials(insecure.NewCredentials())),
+	)
+	if err == nil {
+		t.Fatal("expected warm-up error against dead address")
+	}
+}
+
+func TestHistogramBucketsValidation(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.HistogramBuckets = map[string][]float64{"call_total_ms": {1, 1, 2}}
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for non-increasing boundaries")
+	}
+	cfg.HistogramBuckets = map[string][]float64{"no_such_metric": {1, 2}}
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for unknown histogram")
+	}
+	cfg.HistogramBuckets = map[string][]float64{"call_total_ms": {0.01, 0.1, 1}}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestTCPSamplerValidation(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.TCPSampler.Burst = -1
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for negative burst")
+	}
+
+	// Negative rate and cooldown disable those limits and survive defaults.
+	cfg = DefaultConfig()
+	cfg.TCPSampler.RatePerSec = -1
+	cfg.TCPSampler.Cooldown = -1
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("negative rate and cooldown: %v", err)
+	}
+	if sc := cfg.TCPSampler.withDefaults(); sc.RatePerSec >= 0 || sc.Cooldown >= 0 {
+		t.Errorf("withDefaults replaced negative rate or cooldown: %+v", sc)
+	}
+
+	// Zero fields fall back to the defaults.
+	got := TCPSamplerConfig{Burst: 100}.withDefaults()
+	want := DefaultConfig().TCPSampler
+	want.Burst = 100
+	if got != want {
+		t.Errorf("withDefaults = %+v, want %+v", got, want)
+	}
+}
+
+func TestSlowCallLogValidation(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.SlowCallLog.SampleRate = 1.5
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for SampleRate > 1")
+	}
+
+	cfg = DefaultConfig()
+	cfg.SlowCall.MethodThresholds = map[string]time.Duration{"/svc/M": -time.Second}
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for a negative method threshold")
+	}
+
+	cfg = DefaultConfig()
+	cfg.SlowCallLog.Logger = slog.Default()
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for a slow-call logger without a slow-call condition")
+	}
+	cfg.SlowCall.Percentile = 0.99
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("slow-call logger with a percentile: %v", err)
+	}
+}
@@ -0,0 +1,290 @@
+package rgrpc
+
+import (
+	"cmp"
+	"context"
+	"math"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"google.golang.org/grpc/codes"
+)
+
+// outlierDetector implements Config.OutlierDetection. Calls are counted per
+// remote IP for the current interval; every interval the counts are swapped
+// out and compared across the fleet on the detector's goroutine, which alone
+// owns the ejection state. The picker reads a copy-on-write set of ejected IPs.
+type outlierDetector struct {
+	cfg       OutlierDetectionConfig
+	failCodes map[codes.Code]bool
+	met       *metrics
+
+	mu    sync.RWMutex
+	stats map[string]*outlierStats // current interval, by remote IP
+
+	ejected atomic.Pointer[map[string]struct{}]
+
+	hosts map[string]*outlierHost // evaluation goroutine only
+}
+
+// outlierStats are the calls to one backend in one interval.
+type outlierStats struct {
+	requests atomic.Uint64
+	failures atomic.Uint64
+	wait     *rollingHist // response_wait_ms, never decayed
+}
+
+// outlierHost is the ejection state of a backend that is ejected or was
+// ejected recently.
+type outlierHost struct {
+	ejections int       // decremented for every interval not ejected
+	until     time.Time // zero when not ejected
+}
+
+// newOutlierDetector returns nil when outlier detection is disabled. The
+// detector evaluates every Interval until stop is closed.
+func newOutlierDetector(cfg OutlierDetectionConfig, met *metrics, stop <-chan struct{}) *outlierDetector {
+	if !cfg.Enabled {
+		return nil
+	}
+	d := newOutlierState(cfg.withDefaults(), met)
+	go d.run(stop)
+	return d
+}
+
+// newOutlierState returns a detector without starting its goroutine.
+func newOutlierState(cfg OutlierDetectionConfig, met *metrics) *outlierDetector {
+	d := &outlierDetector{
+		cfg:       cfg,
+		failCodes: make(map[codes.Code]bool, len(cfg.FailureCodes)),
+		met:       met,
+		stats:     make(map[string]*outlierStats),
+		hosts:     make(map[string]*outlierHost),
+	}
+	for _, c := range cfg.FailureCodes {
+		d.failCodes[c] = true
+	}
+	d.ejected.Store(&map[string]struct{}{})
+	return d
+}
+
+func (d *outlierDetector) run(stop <-chan struct{}) {
+	t := time.NewTicker(d.cfg.Interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case now := <-t.C:
+			d.evaluate(context.Background(), now)
+		}
+	}
+}
+
+// isEjected reports whether the backend at ip is ejected.
+func (d *outlierDetector) isEjected(ip string) bool {
+	_, ok := (*d.ejected.Load())[ip]
+	return ok
+}
+
+// record counts a finished attempt on the backend at ip.
+func (d *outlierDetector) record(ip string, code codes.Code, responseWait time.Duration) {
+	if ip == "" || ip == "unknown" {
+		return
+	}
+	d.mu.RLock()
+	s := d.stats[ip]
+	d.mu.RUnlock()
+	if s == nil {
+		d.mu.Lock()
+		if s = d.stats[ip]; s == nil {
+			if len(d.stats) >= maxAttrCacheSize {
+				d.mu.Unlock()
+				return
+			}
+			s = &outlierStats{wait: newRollingHist()}
+			d.stats[ip] = s
+		}
+		d.mu.Unlock()
+	}
+	s.requests.Add(1)
+	if d.failCodes[code] {
+		s.failures.Add(1)
+	}
+	s.wait.add(durMs(responseWait), math.MaxUint64)
+}
+
+// outlierCandidate is a backend with enough calls in the interval.
+type outlierCandidate struct {
+	ip          string
+	successRate float64
+	p99         float64 // ms
+}
+
+// outlier is a candidate found to be an outlier, with the fleet statistics
+// it was compared with.
+type outlier struct {
+	outlierCandidate
+	reason       string
+	fleetSuccess float64
+	fleetP99     float64
+}
+
+// evaluate ends the current interval: it returns backends whose ejection
+// expired and ejects the outliers among the backends called in the interval.
+func (d *outlierDetector) evaluate(ctx context.Context, now time.Time) {
+	d.mu.Lock()
+	stats := d.stats
+	d.stats = make(map[string]*outlierStats, len(stats))
+	d.mu.Unlock()
+
+	// Events are emitted once the ejected set is published, so a handler
+	// sees the picker already acting on them.
+	var events []OutlierEvent
+	changed := false
+	ejected := 0
+	hosts := len(stats)
+	for ip, h := range d.hosts {
+		switch {
+		case !h.until.IsZero() && !now.Before(h.until):
+			h.until = time.Time{}
+			changed = true
+			events = append(events, OutlierEvent{RemoteIP: ip})
+		case !h.until.IsZero():
+			ejected++
+			if _, ok := stats[ip]; !ok {
+				hosts++
+			}
+		case h.ejections > 0:
+			h.ejections--
+		}
+		if h.until.IsZero() && h.ejections == 0 {
+			delete(d.hosts, ip)
+		}
+	}
+
+	var cands []outlierCandidate
+	for ip, s := range stats {
+		n := s.requests.Load()
+		if n < uint64(d.cfg.MinRequests) {
+			continue
+		}
+		if h := d.hosts[ip]; h != nil && !h.until.IsZero() {
+			continue
+		}
+		p99, _ := s.wait.quantile(0.99, 1)
+		cands = append(cands, outlierCandidate{
+			ip:          ip,
+			successRate: 1 - float64(s.failures.Load())/float64(n),
+			p99:         p99,
+		})
+	}
+
+	if len(cands) >= d.cfg.MinHosts {
+		// Always allow one ejection, as Envoy does, so small fleets are
+		// protected too.
+		limit := max(1, hosts*d.cfg.MaxEjectionPercent/100)
+		for _, o := range d.outliers(cands) {
+			if ejected >= limit {
+				break
+			}
+			events = append(events, d.eject(ctx, now, o))
+			ejected++
+			changed = true
+		}
+	}
+
+	if changed {
+		set := make(map[string]struct{}, ejected)
+		for ip, h := range d.hosts {
+			if !h.until.IsZero() {
+				set[ip] = struct{}{}
+			}
+		}
+		d.ejected.Store(&set)
+	}
+	d.met.recordOutliersEjected(ctx, ejected)
+	if d.cfg.OnEvent != nil {
+		for _, ev := range events {
+			d.cfg.OnEvent(ev)
+		}
+	}
+}
+
+// outliers returns the success-rate outliers among cands, lowest success rate
+// first, followed by the latency outliers, highest p99 first.
+func (d *outlierDetector) outliers(cands []outlierCandidate) []outlier {
+	var out []outlier
+
+	var mean, variance float64
+	for _, c := range cands {
+		mean += c.successRate
+	}
+	mean /= float64(len(cands))
+	for _, c := range cands {
+		variance += (c.successRate - mean) * (c.successRate - mean)
+	}
+	stdev := math.Sqrt(variance / float64(len(cands)))
+
+	p99s := make([]float64, len(cands))
+	for i, c := range cands {
+		p99s[i] = c.p99
+	}
+	slices.Sort(p99s)
+	median := p99s[len(p99s)/2]
+	if len(p99s)%2 == 0 {
+		median = (median + p99s[len(p99s)/2-1]) / 2
+	}
+
+	if f := d.cfg.SuccessRateStdevFactor; f > 0 {
+		threshold := mean - f*stdev
+		for _, c := range cands {
+			if c.successRate < threshold {
+				out = append(out, outlier{outlierCandidate: c, reason: OutlierReasonSuccessRate, fleetSuccess: mean, fleetP99: median})
+			}
+		}
+		slices.SortFunc(out, func(a, b outlier) int {
+			return cmp.Compare(a.successRate, b.successRate)
+		})
+	}
+	if f := d.cfg.LatencyFactor; f > 0 {
+		threshold := max(f*median, durMs(d.cfg.MinLatency))
+		n := len(out)
+		for _, c := range cands {
+			if c.p99 > threshold && !slices.ContainsFunc(out[:n], func(o outlier) bool { return o.ip == c.ip }) {
+				out = append(out, outlier{outlierCandidate: c, reason: OutlierReasonLatency, fleetSuccess: mean, fleetP99: median})
+			}
+		}
+		slices.SortFunc(out[n:], func(a, b outlier) int {
+			return cmp.Compare(b.p99, a.p99)
+		})
+	}
+	return out
+}
+
+// eject ejects o for the base ejection time times its ejection count, and
+// returns the event to emit.
+func (d *outlierDetector) eject(ctx context.Context, now time.Time, o outlier) OutlierEvent {
+	h := d.hosts[o.ip]
+	if h == nil {
+		h = &outlierHost{}
+		d.hosts[o.ip] = h
+	}
+	h.ejections++
+	dur := min(d.cfg.BaseEjectionTime*time.Duration(h.ejections), max(d.cfg.MaxEjectionTime, d.cfg.BaseEjectionTime))
+	h.until = now.Add(dur)
+
+	d.met.recordOutlierEjection(ctx, o.ip, o.reason)
+	return OutlierEvent{
+		RemoteIP:             o.ip,
+		Ejected:              true,
+		Reason:               o.reason,
+		Duration:             dur,
+		SuccessRate:          o.successRate,
+		FleetSuccessRate:     o.fleetSuccess,
+		ResponseWaitP99:      time.Duration(o.p99 * float64(time.Millisecond)),
+		FleetResponseWaitP99: time.Duration(o.fleetP99 * float64(time.Millisecond)),
+	}
+}
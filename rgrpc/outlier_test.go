@@ -0,0 +1,217 @@
+package rgrpc
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/resolver"
+	"google.golang.org/grpc/resolver/manual"
+)
+
+func newTestOutlierDetector(t *testing.T, cfg OutlierDetectionConfig) (*outlierDetector, *[]OutlierEvent) {
+	t.Helper()
+	mcfg := DefaultConfig()
+	mcfg.TCPMetricsInterval = 0
+	h := mustNewHooks(t, mcfg)
+	t.Cleanup(h.close)
+
+	var events []OutlierEvent
+	cfg.OnEvent = func(ev OutlierEvent) { events = append(events, ev) }
+	return newOutlierState(cfg.withDefaults(), h.metrics), &events
+}
+
+// feed records n calls to ip, the first failures of them failed, each with a
+// response wait of wait.
+func feed(d *outlierDetector, ip string, n, failures int, wait time.Duration) {
+	for i := 0; i < n; i++ {
+		code := codes.OK
+		if i < failures {
+			code = codes.Unavailable
+		}
+		d.record(ip, code, wait)
+	}
+}
+
+func TestOutlierSuccessRateEjection(t *testing.T) {
+	d, events := newTestOutlierDetector(t, OutlierDetectionConfig{
+		MinRequests:      20,
+		BaseEjectionTime: time.Minute,
+		MaxEjectionTime:  3 * time.Minute,
+	})
+	ctx := context.Background()
+	now := time.Now()
+	ip := func(i int) string { return fmt.Sprintf("10.0.0.%d", i) }
+
+	for i := 1; i <= 5; i++ {
+		feed(d, ip(i), 50, 0, time.Millisecond)
+	}
+	feed(d, ip(6), 50, 25, time.Millisecond)
+	feed(d, ip(7), 5, 5, time.Millisecond) // too few calls to be judged
+	d.evaluate(ctx, now)
+
+	if len(*events) != 1 {
+		t.Fatalf("events = %+v, want one ejection", *events)
+	}
+	ev := (*events)[0]
+	if ev.RemoteIP != ip(6) || !ev.Ejected || ev.Reason != OutlierReasonSuccessRate || ev.Duration != time.Minute {
+		t.Errorf("event = %+v", ev)
+	}
+	if ev.SuccessRate != 0.5 || ev.FleetSuccessRate <= 0.9 {
+		t.Errorf("success rates = %v (fleet %v)", ev.SuccessRate, ev.FleetSuccessRate)
+	}
+	if !d.isEjected(ip(6)) || d.isEjected(ip(7)) {
+		t.Error("ejected set")
+	}
+
+	// A latency outlier is found, but MaxEjectionPercent (10% of 6, at
+	// least one) is already used by the ejected backend.
+	for i := 1; i <= 5; i++ {
+		wait := time.Millisecond
+		if i == 3 {
+			wait = 200 * time.Millisecond
+		}
+		feed(d, ip(i), 50, 0, wait)
+	}
+	d.evaluate(ctx, now.Add(10*time.Second))
+	if len(*events) != 1 || d.isEjected(ip(3)) {
+		t.Fatalf("ejection beyond MaxEjectionPercent: %+v", *events)
+	}
+
+	// The ejection expires; failing again doubles the ejection time.
+	for i := 1; i <= 5; i++ {
+		feed(d, ip(i), 50, 0, time.Millisecond)
+	}
+	feed(d, ip(6), 50, 25, time.Millisecond)
+	d.evaluate(ctx, now.Add(time.Minute))
+	if len(*events) != 3 {
+		t.Fatalf("events = %+v, want return and re-ejection", *events)
+	}
+	if ev := (*events)[1]; ev.RemoteIP != ip(6) || ev.Ejected {
+		t.Errorf("return event = %+v", ev)
+	}
+	if ev := (*events)[2]; ev.RemoteIP != ip(6) || !ev.Ejected || ev.Duration != 2*time.Minute {
+		t.Errorf("second ejection = %+v, want 2m", ev)
+	}
+}
+
+func TestOutlierLatencyEjection(t *testing.T) {
+	d, events := newTestOutlierDetector(t, OutlierDetectionConfig{
+		MinRequests:        20,
+		MaxEjectionPercent: 50,
+	})
+	for i := 1; i <= 5; i++ {
+		wait := 2 * time.Millisecond
+		if i == 2 {
+			wait = 100 * time.Millisecond
+		}
+		feed(d, fmt.Sprintf("10.0.0.%d", i), 50, 0, wait)
+	}
+	d.evaluate(context.Background(), time.Now())
+
+	if len(*events) != 1 {
+		t.Fatalf("events = %+v, want one ejection", *events)
+	}
+	ev := (*events)[0]
+	if ev.RemoteIP != "10.0.0.2" || ev.Reason != OutlierReasonLatency {
+		t.Errorf("event = %+v", ev)
+	}
+	if ev.ResponseWaitP99 < 50*time.Millisecond || ev.FleetResponseWaitP99 > 5*time.Millisecond {
+		t.Errorf("p99 = %v, fleet %v", ev.ResponseWaitP99, ev.FleetResponseWaitP99)
+	}
+
+	// Below MinLatency nothing is an outlier, however large the ratio.
+	d, events = newTestOutlierDetector(t, OutlierDetectionConfig{MinRequests: 20, MaxEjectionPercent: 50})
+	for i := 1; i <= 5; i++ {
+		wait := 100 * time.Microsecond
+		if i == 2 {
+			wait = 5 * time.Millisecond
+		}
+		feed(d, fmt.Sprintf("10.0.0.%d", i), 50, 0, wait)
+	}
+	d.evaluate(context.Background(), time.Now())
+	if len(*events) != 0 {
+		t.Errorf("events = %+v, want none below MinLatency", *events)
+	}
+}
+
+// TestOutlierEjectionSkipsBackend verifies that the picker stops sending
+// calls to a backend once outlier detection ejects it. With retries, every
+// call succeeds on another backend, but the failed attempts still count
+// against the bad one.
+func TestOutlierEjectionSkipsBackend(t *testing.T) {
+	t.Run("NoRetries", func(t *testing.T) { testOutlierEjectionSkipsBackend(t) })
+	t.Run("Retries", func(t *testing.T) {
+		testOutlierEjectionSkipsBackend(t,
+			WithRetryPolicy(RetryPolicy{MaxAttempts: 2, InitialBackoff: time.Millisecond}),
+			func(o *clientOptions) { o.cfg.Retry.Budget = RetryBudget{Ratio: 1, MaxTokens: 1000} },
+		)
+	})
+}
+
+func testOutlierEjectionSkipsBackend(t *testing.T, opts ...Option) {
+	var addrs []resolver.Address
+	for i := 1; i <= 4; i++ {
+		addr, _ := startHealthServerOn(t, fmt.Sprintf("127.0.0.%d", i), codes.OK)
+		addrs = append(addrs, resolver.Address{Addr: addr})
+	}
+	badAddr, badCalls := startHealthServerOn(t, "127.0.0.5", codes.Unavailable)
+	addrs = append(addrs, resolver.Address{Addr: badAddr})
+
+	r := manual.NewBuilderWithScheme("rgrpcoutlier")
+	r.InitialState(resolver.State{Addresses: addrs})
+
+	ejected := make(chan OutlierEvent, 10)
+	cc, err := New(context.Background(), "rgrpcoutlier:///svc", append([]Option{
+		WithTCPSampling(0),
+		WithClientSideLB(true),
+		WithResolver(r),
+		WithOutlierDetection(OutlierDetectionConfig{
+			Interval:         50 * time.Millisecond,
+			MinRequests:      5,
+			BaseEjectionTime: time.Minute,
+			OnEvent:          func(ev OutlierEvent) { ejected <- ev },
+		}),
+		WithMeterProvider(sdkmetric.NewMeterProvider(sdkmetric.WithReader(sdkmetric.NewManualReader()))),
+		WithDialOptions(grpc.WithTransportCredentials(insecure.NewCredentials())),
+	}, opts...)...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cc.Close()
+	client := healthpb.NewHealthClient(cc)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	var ev OutlierEvent
+wait:
+	for {
+		select {
+		case ev = <-ejected:
+			break wait
+		case <-ctx.Done():
+			t.Fatal("no ejection")
+		default:
+			client.Check(ctx, &healthpb.HealthCheckRequest{})
+		}
+	}
+	if ev.RemoteIP != "127.0.0.5" || ev.Reason != OutlierReasonSuccessRate {
+		t.Fatalf("event = %+v", ev)
+	}
+
+	before := badCalls.Load()
+	for i := 0; i < 50; i++ {
+		if _, err := client.Check(ctx, &healthpb.HealthCheckRequest{}); err != nil {
+			t.Fatalf("call %d after ejection: %v", i, err)
+		}
+	}
+	if got := badCalls.Load(); got != before {
+		t.Errorf("ejected backend got %d more calls", got-before)
+	}
+}
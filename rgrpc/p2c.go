@@ -0,0 +1,156 @@
+package rgrpc
+
+import (
+	"math"
+	"math/rand/v2"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"google.golang.org/grpc/balancer"
+	"google.golang.org/grpc/balancer/base"
+)
+
+// p2cBalancerName is the latency-aware policy selected by
+// LoadBalancingP2CEWMA. It picks two random ready subchannels and sends the
+// call to the one with the lower cost: the EWMA of its attempts'
+// response_wait_ms, times its in-flight calls plus one.
+const p2cBalancerName = "rgrpc_p2c_ewma"
+
+// p2cDecay is the time constant of the response-wait EWMA. It also decays
+// the cost of an idle subchannel, so a backend that was slow is tried again
+// after a few multiples of it.
+const p2cDecay = 10 * time.Second
+
+func init() {
+	balancer.Register(p2cBuilder{})
+}
+
+type p2cBuilder struct{}
+
+func (p2cBuilder) Name() string { return p2cBalancerName }
+
+// Build gives every balancer its own picker builder, so per-subchannel load
+// survives picker updates but is not shared between clients. The ClientConn
+// wrapper is the one of the timed policies, so picks are timed and circuit
+// breakers apply.
+func (p2cBuilder) Build(cc balancer.ClientConn, opts balancer.BuildOptions) balancer.Balancer {
+	pb := &p2cPickerBuilder{loads: make(map[balancer.SubConn]*subConnLoad)}
+	return base.NewBalancerBuilder(p2cBalancerName, pb, base.Config{}).
+		Build(&timedBalancerCC{ClientConn: cc, ips: &subConnIPs{}}, opts)
+}
+
+type p2cPickerBuilder struct {
+	mu    sync.Mutex
+	loads map[balancer.SubConn]*subConnLoad
+}
+
+func (b *p2cPickerBuilder) Build(info base.PickerBuildInfo) balancer.Picker {
+	if len(info.ReadySCs) == 0 {
+		return base.NewErrPicker(balancer.ErrNoSubConnAvailable)
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for sc := range b.loads {
+		if _, ok := info.ReadySCs[sc]; !ok {
+			delete(b.loads, sc)
+		}
+	}
+	p := &p2cPicker{
+		scs:   make([]balancer.SubConn, 0, len(info.ReadySCs)),
+		loads: make([]*subConnLoad, 0, len(info.ReadySCs)),
+	}
+	for sc := range info.ReadySCs {
+		l := b.loads[sc]
+		if l == nil {
+			l = &subConnLoad{}
+			b.loads[sc] = l
+		}
+		p.scs = append(p.scs, sc)
+		p.loads = append(p.loads, l)
+	}
+	return p
+}
+
+// subConnLoad is the load estimate of one subchannel.
+type subConnLoad struct {
+	inflight atomic.Int64
+
+	mu       sync.Mutex
+	ewma     float64 // response wait, ms
+	lastUnix int64   // last sample
+}
+
+// observe adds a response-wait sample. Like Finagle's peak EWMA, a sample
+// above the average replaces it, so a backend turning slow is avoided at
+// once; lower samples are blended in with a weight growing with the time
+// since the previous one. A failed attempt counts as at least twice the
+// average, so a backend failing fast does not attract traffic.
+func (l *subConnLoad) observe(ms float64, failed bool, now int64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if failed {
+		ms = max(ms, 2*l.ewma)
+	}
+	if ms >= l.ewma || l.lastUnix == 0 {
+		l.ewma = ms
+	} else {
+		w := math.Exp(-float64(now-l.lastUnix) / float64(p2cDecay))
+		l.ewma = l.ewma*w + ms*(1-w)
+	}
+	l.lastUnix = now
+}
+
+// cost is the decayed EWMA, plus 1ms so in-flight calls count for a
+// subchannel without samples, times the in-flight calls plus one.
+func (l *subConnLoad) cost(now int64) float64 {
+	l.mu.Lock()
+	ewma := l.ewma
+	if l.lastUnix != 0 {
+		ewma *= math.Exp(-float64(now-l.lastUnix) / float64(p2cDecay))
+	}
+	l.mu.Unlock()
+	return (ewma + 1) * float64(l.inflight.Load()+1)
+}
+
+type p2cPicker struct {
+	scs   []balancer.SubConn
+	loads []*subConnLoad
+}
+
+func (p *p2cPicker) Pick(info balancer.PickInfo) (balancer.PickResult, error) {
+	i := 0
+	if n := len(p.scs); n > 1 {
+		now := unixNow()
+		i = rand.IntN(n)
+		if j := (i + 1 + rand.IntN(n-1)) % n; p.loads[j].cost(now) < p.loads[i].cost(now) {
+			i = j
+		}
+	}
+
+	l := p.loads[i]
+	l.inflight.Add(1)
+	// TagRPC runs before the pick, so the attempt's phase timestamps are
+	// available when it is done.
+	a := attemptStateFrom(info.Ctx)
+	return balancer.PickResult{
+		SubConn: p.scs[i],
+		Done: func(di balancer.DoneInfo) {
+			l.inflight.Add(-1)
+			// Attempts that sent nothing (e.g. re-picked, or failed to
+			// start a stream) say nothing about the backend's latency.
+			if a == nil {
+				return
+			}
+			op := a.outPayloadUnix.Load()
+			if op == 0 {
+				return
+			}
+			end := unixNow()
+			if ip := a.inPayloadUnix.Load(); ip > 0 {
+				end = ip
+			}
+			l.observe(durMs(time.Duration(end-op)), di.Err != nil, unixNow())
+		},
+	}, nil
+}
@@ -0,0 +1,115 @@
+package rgrpc
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/resolver"
+	"google.golang.org/grpc/resolver/manual"
+)
+
+// startLatencyServer starts a health server answering after delay and returns
+// its address and a call counter.
+func startLatencyServer(t *testing.T, delay time.Duration) (string, *atomic.Int64) {
+	t.Helper()
+	var calls atomic.Int64
+	addr := startHealthServerWith(t, "127.0.0.1", func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		calls.Add(1)
+		time.Sleep(delay)
+		return handler(ctx, req)
+	})
+	return addr, &calls
+}
+
+// TestP2CEWMAShiftsTraffic simulates three backends, one much slower than the
+// others, and verifies that p2c_ewma sends it a small share of the calls where
+// round robin would send it a third.
+func TestP2CEWMAShiftsTraffic(t *testing.T) {
+	fast1, fast1Calls := startLatencyServer(t, time.Millisecond)
+	fast2, fast2Calls := startLatencyServer(t, time.Millisecond)
+	slow, slowCalls := startLatencyServer(t, 30*time.Millisecond)
+
+	r := manual.NewBuilderWithScheme("rgrpcp2c")
+	r.InitialState(resolver.State{Addresses: []resolver.Address{{Addr: fast1}, {Addr: fast2}, {Addr: slow}}})
+
+	cc, err := New(context.Background(), "rgrpcp2c:///svc",
+		WithTCPSampling(0),
+		WithLoadBalancingPolicy(LoadBalancingP2CEWMA),
+		WithResolver(r),
+		WithMeterProvider(sdkmetric.NewMeterProvider(sdkmetric.WithReader(sdkmetric.NewManualReader()))),
+		WithDialOptions(grpc.WithTransportCredentials(insecure.NewCredentials())),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cc.Close()
+	client := healthpb.NewHealthClient(cc)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+	const workers, perWorker = 4, 75
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < perWorker; i++ {
+				if _, err := client.Check(ctx, &healthpb.HealthCheckRequest{}); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	total := fast1Calls.Load() + fast2Calls.Load() + slowCalls.Load()
+	if total != workers*perWorker {
+		t.Fatalf("servers saw %d calls, want %d", total, workers*perWorker)
+	}
+	if share := float64(slowCalls.Load()) / float64(total); share > 0.1 {
+		t.Errorf("slow backend got %.0f%% of calls (%d fast1, %d fast2, %d slow), want <= 10%%",
+			100*share, fast1Calls.Load(), fast2Calls.Load(), slowCalls.Load())
+	}
+	if fast1Calls.Load() == 0 || fast2Calls.Load() == 0 {
+		t.Errorf("fast backends not both used: %d, %d", fast1Calls.Load(), fast2Calls.Load())
+	}
+}
+
+func TestSubConnLoad(t *testing.T) {
+	var l subConnLoad
+	now := time.Now().UnixNano()
+	if c := l.cost(now); c != 1 {
+		t.Errorf("cost without samples = %v, want 1", c)
+	}
+
+	l.observe(10, false, now)
+	l.observe(50, false, now) // a peak replaces the average
+	if l.ewma != 50 {
+		t.Errorf("ewma after peak = %v, want 50", l.ewma)
+	}
+	l.observe(10, false, now+int64(p2cDecay)) // lower samples blend in
+	if l.ewma <= 10 || l.ewma >= 50 {
+		t.Errorf("ewma after decay = %v, want between 10 and 50", l.ewma)
+	}
+	l.observe(1, true, now+int64(p2cDecay))
+	if l.ewma < 20 {
+		t.Errorf("ewma after failure = %v, want at least twice the average", l.ewma)
+	}
+
+	l.inflight.Add(2)
+	at := now + int64(p2cDecay)
+	if got, want := l.cost(at), (l.ewma+1)*3; got != want {
+		t.Errorf("cost = %v, want %v", got, want)
+	}
+	if l.cost(at+int64(5*p2cDecay)) >= l.cost(at) {
+		t.Error("cost of an idle subchannel must decay")
+	}
+}
@@ -0,0 +1,103 @@
+package rgrpc
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/stats"
+)
+
+// emitUnaryCall drives one synthetic unary call through h.
+func emitUnaryCall(t *testing.T, h *hooks, method string) {
+	t.Helper()
+	sh := newStatsHandler(h)
+	ui := newUnaryInterceptor(h)
+	err := ui(context.Background(), method, nil, nil, nil,
+		func(ctx context.Context, _ string, _, _ any, _ *grpc.ClientConn, _ ...grpc.CallOption) error {
+			sh.HandleRPC(ctx, &stats.OutHeader{})
+			sh.HandleRPC(ctx, &stats.OutPayload{SentTime: time.Now()})
+			sh.HandleRPC(ctx, &stats.InPayload{RecvTime: time.Now()})
+			sh.HandleRPC(ctx, &stats.End{EndTime: time.Now()})
+			return nil
+		})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// collectMetricNames returns the names of all metrics collected by r.
+func collectMetricNames(t *testing.T, r sdkmetric.Reader) map[string]bool {
+	t.Helper()
+	var rm metricdata.ResourceMetrics
+	if err := r.Collect(context.Background(), &rm); err != nil {
+		t.Fatal(err)
+	}
+	names := make(map[string]bool)
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			names[m.Name] = true
+		}
+	}
+	return names
+}
+
+// TestPerClientMeterProvider verifies that two clients can record into
+// different providers.
+func TestPerClientMeterProvider(t *testing.T) {
+	r1, r2 := sdkmetric.NewManualReader(), sdkmetric.NewManualReader()
+
+	cfg1 := DefaultConfig()
+	cfg1.TCPMetricsInterval = 0
+	cfg1.MetricPrefix = "one"
+	cfg1.MeterProvider = sdkmetric.NewMeterProvider(sdkmetric.WithReader(r1))
+	cfg2 := cfg1
+	cfg2.MetricPrefix = "two"
+	cfg2.MeterProvider = sdkmetric.NewMeterProvider(sdkmetric.WithReader(r2))
+
+	h1, h2 := mustNewHooks(t, cfg1), mustNewHooks(t, cfg2)
+	defer h1.close()
+	defer h2.close()
+
+	emitUnaryCall(t, h1, "/svc/A")
+	emitUnaryCall(t, h2, "/svc/B")
+
+	if n := collectMetricNames(t, r1); !n["one.call_total_ms"] || n["two.call_total_ms"] {
+		t.Errorf("provider 1 got %v", n)
+	}
+	if n := collectMetricNames(t, r2); !n["two.call_total_ms"] || n["one.call_total_ms"] {
+		t.Errorf("provider 2 got %v", n)
+	}
+}
+
+// TestGlobalProviderSetAfterClient verifies that a client created before
+// otel.SetMeterProvider still records into the provider once it is set.
+func TestGlobalProviderSetAfterClient(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.TCPMetricsInterval = 0
+	cfg.MetricPrefix = "late"
+
+	h := mustNewHooks(t, cfg) // bound to the global delegate, no provider installed yet
+	defer h.close()
+
+	// The global delegate cannot be unset, so restore the previous global
+	// provider and shut this one down for the tests that follow.
+	r := sdkmetric.NewManualReader()
+	mp := sdkmetric.NewMeterProvider(sdkmetric.WithReader(r))
+	prev := otel.GetMeterProvider()
+	otel.SetMeterProvider(mp)
+	t.Cleanup(func() {
+		otel.SetMeterProvider(prev)
+		_ = mp.Shutdown(context.Background())
+	})
+
+	emitUnaryCall(t, h, "/svc/Late")
+
+	if n := collectMetricNames(t, r); !n["late.call_total_ms"] {
+		t.Errorf("late-installed provider got %v, want late.call_total_ms", n)
+	}
+}
@@ -0,0 +1,219 @@
+package rgrpc
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// methodLatencies keeps a rolling latency histogram per method. It feeds the
+// slow-call percentile threshold and DebugHandler's per-method percentiles.
+type methodLatencies struct {
+	window uint64 // observations between decays
+
+	mu sync.RWMutex
+	m  map[string]*rollingHist
+}
+
+const rollingHistWindow = 2048
+
+func newMethodLatencies(window uint64) *methodLatencies {
+	return &methodLatencies{
+		window: max(rollingHistWindow, window),
+		m:      make(map[string]*rollingHist),
+	}
+}
+
+func (l *methodLatencies) add(method string, latency time.Duration) {
+	if h := l.hist(method); h != nil {
+		h.add(durMs(latency), l.window)
+	}
+}
+
+// hist returns the history of method, or nil once maxAttrCacheSize methods are
+// tracked (new methods are then not tracked).
+func (l *methodLatencies) hist(method string) *rollingHist {
+	l.mu.RLock()
+	h := l.m[method]
+	l.mu.RUnlock()
+	if h != nil {
+		return h
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if h = l.m[method]; h != nil {
+		return h
+	}
+	if len(l.m) >= maxAttrCacheSize {
+		return nil
+	}
+	h = newRollingHist()
+	l.m[method] = h
+	return h
+}
+
+// snapshot returns the tracked methods and their histograms.
+func (l *methodLatencies) snapshot() map[string]*rollingHist {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	out := make(map[string]*rollingHist, len(l.m))
+	for k, v := range l.m {
+		out[k] = v
+	}
+	return out
+}
+
+// slowCallDetector decides whether a finished call is slow: slower than an
+// absolute threshold (per method or global), or slower than the rolling
+// percentile of its own method. Slow calls get an on-demand TCP_INFO sample of
+// their connection and, optionally, a log record.
+type slowCallDetector struct {
+	threshold        time.Duration
+	methodThresholds map[string]time.Duration
+	percentile       float64
+	minSamples       uint64
+	lat              *methodLatencies
+}
+
+const defaultSlowCallMinSamples = 100
+
+// newSlowCallDetector returns nil when no threshold is configured.
+func newSlowCallDetector(cfg SlowCallConfig, lat *methodLatencies) *slowCallDetector {
+	if !cfg.enabled() {
+		return nil
+	}
+	d := &slowCallDetector{
+		threshold:  cfg.Threshold,
+		percentile: cfg.Percentile,
+		minSamples: slowCallMinSamples(cfg),
+		lat:        lat,
+	}
+	if len(cfg.MethodThresholds) > 0 {
+		d.methodThresholds = make(map[string]time.Duration, len(cfg.MethodThresholds))
+		for k, v := range cfg.MethodThresholds {
+			d.methodThresholds[k] = v
+		}
+	}
+	return d
+}
+
+func slowCallMinSamples(cfg SlowCallConfig) uint64 {
+	if cfg.MinSamples > 0 {
+		return uint64(cfg.MinSamples)
+	}
+	return defaultSlowCallMinSamples
+}
+
+// latencyWindow is the methodLatencies decay window for cfg: decay must not
+// drop a method's sample count below MinSamples for good.
+func latencyWindow(cfg SlowCallConfig) uint64 {
+	return 4 * slowCallMinSamples(cfg)
+}
+
+// isSlow reports whether a call of method taking latency is slow. It must be
+// called before the call is added to lat, so an outlier does not raise its own
+// bar.
+func (d *slowCallDetector) isSlow(method string, latency time.Duration) bool {
+	_, slow := d.limit(method, latency)
+	return slow
+}
+
+// limit is isSlow that also returns the threshold the call exceeded.
+func (d *slowCallDetector) limit(method string, latency time.Duration) (time.Duration, bool) {
+	threshold := d.threshold
+	if t, ok := d.methodThresholds[method]; ok {
+		threshold = t
+	}
+	if threshold > 0 && latency >= threshold {
+		return threshold, true
+	}
+	if d.percentile <= 0 {
+		return 0, false
+	}
+	h := d.lat.hist(method)
+	if h == nil {
+		return 0, false
+	}
+	q, ok := h.quantile(d.percentile, d.minSamples)
+	if !ok || durMs(latency) <= q {
+		return 0, false
+	}
+	return time.Duration(q * float64(time.Millisecond)), true
+}
+
+// rollingHist is a decaying histogram over latencyBucketsMs. All counts are
+// halved every window observations, so quantiles follow the recent past
+// without storing samples. Updates are lock-free and approximate under
+// concurrency, which is fine for a threshold.
+type rollingHist struct {
+	counts     []atomic.Uint64 // len(latencyBucketsMs)+1; the last is overflow
+	sinceDecay atomic.Uint64
+	decaying   atomic.Bool
+}
+
+func newRollingHist() *rollingHist {
+	return &rollingHist{counts: make([]atomic.Uint64, len(latencyBucketsMs)+1)}
+}
+
+func (h *rollingHist) add(ms float64, window uint64) {
+	i := 0
+	for i < len(latencyBucketsMs) && ms > latencyBucketsMs[i] {
+		i++
+	}
+	h.counts[i].Add(1)
+
+	if h.sinceDecay.Add(1) >= window && h.decaying.CompareAndSwap(false, true) {
+		h.sinceDecay.Store(0)
+		for j := range h.counts {
+			for {
+				v := h.counts[j].Load()
+				if h.counts[j].CompareAndSwap(v, v/2) {
+					break
+				}
+			}
+		}
+		h.decaying.Store(false)
+	}
+}
+
+// quantile estimates the q-th quantile in ms by linear interpolation within
+// its bucket. ok is false until the histogram holds minSamples observations.
+// Counts are read twice without a snapshot (no allocation on the call path);
+// concurrent updates only shift the estimate slightly.
+func (h *rollingHist) quantile(q float64, minSamples uint64) (float64, bool) {
+	total := h.count()
+	if total == 0 || total < minSamples {
+		return 0, false
+	}
+
+	rank := q * float64(total)
+	var cum float64
+	for i := range h.counts {
+		c := h.counts[i].Load()
+		if c == 0 || cum+float64(c) < rank {
+			cum += float64(c)
+			continue
+		}
+		if i == len(latencyBucketsMs) {
+			// Overflow bucket has no upper bound.
+			return latencyBucketsMs[i-1], true
+		}
+		lower := 0.0
+		if i > 0 {
+			lower = latencyBucketsMs[i-1]
+		}
+		upper := latencyBucketsMs[i]
+		return lower + (upper-lower)*(rank-cum)/float64(c), true
+	}
+	return latencyBucketsMs[len(latencyBucketsMs)-1], true
+}
+
+// count returns the current (decayed) number of observations.
+func (h *rollingHist) count() uint64 {
+	var total uint64
+	for i := range h.counts {
+		total += h.counts[i].Load()
+	}
+	return total
+}
@@ -0,0 +1,72 @@
+package rgrpc
+
+import (
+	"testing"
+	"time"
+)
+
+// TestSlowCallDetectorPercentile verifies that the percentile condition waits
+// for MinSamples, then flags calls above the method's own p99.
+func TestSlowCallDetectorPercentile(t *testing.T) {
+	cfg := SlowCallConfig{Percentile: 0.99, MinSamples: 50}
+	lat := newMethodLatencies(latencyWindow(cfg))
+	d := newSlowCallDetector(cfg, lat)
+
+	if d.isSlow("/svc/Fast", time.Second) {
+		t.Error("call flagged before MinSamples were seen")
+	}
+	for i := 0; i < 1000; i++ {
+		lat.add("/svc/Fast", 2*time.Millisecond)
+		lat.add("/svc/Slow", 400*time.Millisecond)
+	}
+
+	if d.isSlow("/svc/Fast", 2*time.Millisecond) {
+		t.Error("typical fast call flagged as slow")
+	}
+	if !d.isSlow("/svc/Fast", 50*time.Millisecond) {
+		t.Error("50ms call not flagged for a 2ms method")
+	}
+	if d.isSlow("/svc/Slow", 400*time.Millisecond) {
+		t.Error("typical call of the slow method flagged as slow")
+	}
+}
+
+func TestSlowCallDetectorThreshold(t *testing.T) {
+	lat := newMethodLatencies(0)
+	if newSlowCallDetector(SlowCallConfig{}, lat) != nil {
+		t.Fatal("expected nil detector when disabled")
+	}
+	d := newSlowCallDetector(SlowCallConfig{Threshold: 100 * time.Millisecond}, lat)
+	if d.isSlow("/svc/M", 99*time.Millisecond) || !d.isSlow("/svc/M", 100*time.Millisecond) {
+		t.Error("threshold not applied")
+	}
+
+	d = newSlowCallDetector(SlowCallConfig{
+		Threshold:        100 * time.Millisecond,
+		MethodThresholds: map[string]time.Duration{"/svc/Batch": time.Second, "/svc/Off": 0},
+	}, lat)
+	if d.isSlow("/svc/Batch", 500*time.Millisecond) {
+		t.Error("method threshold did not override the global one")
+	}
+	if limit, slow := d.limit("/svc/Batch", time.Second); !slow || limit != time.Second {
+		t.Errorf("limit = %v, %v; want 1s, true", limit, slow)
+	}
+	if d.isSlow("/svc/Off", time.Minute) {
+		t.Error("zero method threshold did not disable the absolute threshold")
+	}
+}
+
+func TestRollingHistDecay(t *testing.T) {
+	lat := newMethodLatencies(0)
+	for i := 0; i < rollingHistWindow; i++ {
+		lat.add("/svc/M", time.Second)
+	}
+	// After the window the old regime is halved away by the new one.
+	for i := 0; i < 4*rollingHistWindow; i++ {
+		lat.add("/svc/M", time.Millisecond)
+	}
+	h := lat.hist("/svc/M")
+	if q, ok := h.quantile(0.5, 1); !ok || q > 1 {
+		t.Errorf("median = %v (ok=%v), want <= 1ms after decay", q, ok)
+	}
+}
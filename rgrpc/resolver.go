@@ -0,0 +1,161 @@
+package rgrpc
+
+import (
+	"context"
+	"net/url"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/resolver"
+)
+
+// timedResolverBuilder wraps the real resolver builder for the client's target
+// scheme (dns, passthrough, or a custom one) and measures what it does:
+// resolution latency, errors, the number of addresses returned, and address
+// churn between updates. It is installed per client with grpc.WithResolvers,
+// so the global resolver registry is left untouched.
+type timedResolverBuilder struct {
+	inner  resolver.Builder
+	met    *metrics
+	target string
+}
+
+func newTimedResolverBuilder(inner resolver.Builder, met *metrics, target string) resolver.Builder {
+	return &timedResolverBuilder{inner: inner, met: met, target: target}
+}
+
+func (b *timedResolverBuilder) Scheme() string { return b.inner.Scheme() }
+
+func (b *timedResolverBuilder) Build(target resolver.Target, cc resolver.ClientConn, opts resolver.BuildOptions) (resolver.Resolver, error) {
+	tcc := &timedResolverCC{
+		ClientConn: cc,
+		met:        b.met,
+		scheme:     b.inner.Scheme(),
+		target:     b.target,
+	}
+	// Set before Build: resolvers such as passthrough update synchronously.
+	tcc.markPending()
+
+	r, err := b.inner.Build(target, tcc, opts)
+	if err != nil {
+		b.met.recordResolverError(context.Background(), tcc.scheme, tcc.target)
+		return nil, err
+	}
+	return &timedResolver{Resolver: r, cc: tcc}, nil
+}
+
+type timedResolver struct {
+	resolver.Resolver
+	cc *timedResolverCC
+}
+
+func (r *timedResolver) ResolveNow(o resolver.ResolveNowOptions) {
+	r.cc.markPending()
+	r.Resolver.ResolveNow(o)
+}
+
+// timedResolverCC intercepts the resolver's results on their way to gRPC.
+type timedResolverCC struct {
+	resolver.ClientConn
+	met    *metrics
+	scheme string
+	target string
+
+	mu           sync.Mutex
+	pendingSince time.Time           // zero when no resolution was requested
+	prev         map[string]struct{} // addresses of the previous update
+}
+
+func (c *timedResolverCC) markPending() {
+	c.mu.Lock()
+	if c.pendingSince.IsZero() {
+		c.pendingSince = time.Now()
+	}
+	c.mu.Unlock()
+}
+
+// takePending returns the time since resolution was requested and clears it.
+// ok is false for unsolicited updates (e.g. periodic re-resolution or watches).
+func (c *timedResolverCC) takePending() (time.Duration, bool) {
+	if c.pendingSince.IsZero() {
+		return 0, false
+	}
+	d := time.Since(c.pendingSince)
+	c.pendingSince = time.Time{}
+	return d, true
+}
+
+func (c *timedResolverCC) UpdateState(s resolver.State) error {
+	cur := make(map[string]struct{})
+	for _, a := range s.Addresses {
+		cur[a.Addr] = struct{}{}
+	}
+	for _, e := range s.Endpoints {
+		for _, a := range e.Addresses {
+			cur[a.Addr] = struct{}{}
+		}
+	}
+
+	c.mu.Lock()
+	latency, solicited := c.takePending()
+	var added, removed int
+	for a := range cur {
+		if _, ok := c.prev[a]; !ok {
+			added++
+		}
+	}
+	for a := range c.prev {
+		if _, ok := cur[a]; !ok {
+			removed++
+		}
+	}
+	c.prev = cur
+	c.mu.Unlock()
+
+	ctx := context.Background()
+	if solicited {
+		c.met.recordResolverLatency(ctx, c.scheme, c.target, latency)
+	}
+	c.met.recordResolverUpdate(ctx, c.scheme, c.target, len(cur), added, removed)
+
+	return c.ClientConn.UpdateState(s)
+}
+
+func (c *timedResolverCC) ReportError(err error) {
+	c.mu.Lock()
+	latency, solicited := c.takePending()
+	c.mu.Unlock()
+
+	ctx := context.Background()
+	if solicited {
+		c.met.recordResolverLatency(ctx, c.scheme, c.target, latency)
+	}
+	c.met.recordResolverError(ctx, c.scheme, c.target)
+
+	c.ClientConn.ReportError(err)
+}
+
+// resolverBuilderFor returns the builder gRPC would pick for target, mirroring
+// grpc.NewClient: the target's URL scheme if a resolver is registered for it,
+// otherwise the default scheme ("dns" for NewClient, unless the process changed
+// it with resolver.SetDefaultScheme). custom, if non-nil, takes precedence for
+// its own scheme. Returns nil if no builder is found.
+func resolverBuilderFor(target string, custom resolver.Builder) resolver.Builder {
+	get := func(scheme string) resolver.Builder {
+		if custom != nil && custom.Scheme() == scheme {
+			return custom
+		}
+		return resolver.Get(scheme)
+	}
+
+	if u, err := url.Parse(target); err == nil && u.Scheme != "" {
+		if b := get(u.Scheme); b != nil {
+			return b
+		}
+	}
+	scheme := "dns"
+	if s := resolver.GetDefaultScheme(); s != "passthrough" {
+		scheme = s
+	}
+	return get(scheme)
+}
@@ -0,0 +1,117 @@
+package rgrpc
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/resolver"
+	"google.golang.org/grpc/resolver/manual"
+)
+
+// int64Value returns the value of the single data point of the named gauge or counter.
+func int64Value(t *testing.T, r sdkmetric.Reader, name string) int64 {
+	t.Helper()
+	var rm metricdata.ResourceMetrics
+	if err := r.Collect(context.Background(), &rm); err != nil {
+		t.Fatal(err)
+	}
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			if m.Name != name {
+				continue
+			}
+			switch d := m.Data.(type) {
+			case metricdata.Gauge[int64]:
+				return d.DataPoints[0].Value
+			case metricdata.Sum[int64]:
+				return d.DataPoints[0].Value
+			}
+		}
+	}
+	t.Fatalf("metric %s not found", name)
+	return 0
+}
+
+// TestResolverMetrics verifies that the wrapped resolver reports address counts,
+// churn between updates, and latency of the initial resolution.
+func TestResolverMetrics(t *testing.T) {
+	r := manual.NewBuilderWithScheme("rgrpctest")
+	r.InitialState(resolver.State{Addresses: []resolver.Address{
+		{Addr: "127.0.0.1:1"}, {Addr: "127.0.0.1:2"},
+	}})
+
+	reader := sdkmetric.NewManualReader()
+	cc, err := New(context.Background(), "rgrpctest:///svc",
+		WithTCPSampling(0),
+		WithResolver(r),
+		WithResolverMetrics(true),
+		WithMeterProvider(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))),
+		WithDialOptions(grpc.WithTransportCredentials(insecure.NewCredentials())),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cc.Close()
+	cc.Connect() // leave idle mode so the resolver is built
+
+	if got := int64Value(t, reader, "rgrpc.resolver_addresses"); got != 2 {
+		t.Errorf("resolver_addresses = %d, want 2", got)
+	}
+	if got := len(seriesAttrs(t, reader, "rgrpc.resolver_latency_ms")); got != 1 {
+		t.Errorf("resolver_latency_ms: expected 1 series, got %d", got)
+	}
+
+	r.UpdateState(resolver.State{Addresses: []resolver.Address{
+		{Addr: "127.0.0.1:2"}, {Addr: "127.0.0.1:3"}, {Addr: "127.0.0.1:4"},
+	}})
+
+	if got := int64Value(t, reader, "rgrpc.resolver_addresses"); got != 3 {
+		t.Errorf("resolver_addresses = %d, want 3", got)
+	}
+	if got := int64Value(t, reader, "rgrpc.resolver_addresses_added"); got != 4 {
+		t.Errorf("resolver_addresses_added = %d, want 4 (2 initial + 2)", got)
+	}
+	if got := int64Value(t, reader, "rgrpc.resolver_addresses_removed"); got != 1 {
+		t.Errorf("resolver_addresses_removed = %d, want 1", got)
+	}
+
+	attrs := seriesAttrs(t, reader, "rgrpc.resolver_addresses_added")
+	if v, _ := attrs[0].Value(attribute.Key("scheme")); v.AsString() != "rgrpctest" {
+		t.Errorf("scheme = %q, want rgrpctest", v.AsString())
+	}
+}
+
+// TestCallerResolverNotShadowed verifies that by default a resolver passed
+// with grpc.WithResolvers for a globally registered scheme is the one used.
+func TestCallerResolverNotShadowed(t *testing.T) {
+	r := manual.NewBuilderWithScheme("dns")
+	built := make(chan struct{}, 1)
+	r.BuildCallback = func(resolver.Target, resolver.ClientConn, resolver.BuildOptions) { built <- struct{}{} }
+	r.InitialState(resolver.State{Addresses: []resolver.Address{{Addr: "127.0.0.1:1"}}})
+
+	cc, err := New(context.Background(), "dns:///svc",
+		WithTCPSampling(0),
+		WithMeterProvider(sdkmetric.NewMeterProvider(sdkmetric.WithReader(sdkmetric.NewManualReader()))),
+		WithDialOptions(
+			grpc.WithTransportCredentials(insecure.NewCredentials()),
+			grpc.WithResolvers(r),
+		),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cc.Close()
+	cc.Connect()
+
+	select {
+	case <-built:
+	case <-time.After(5 * time.Second):
+		t.Fatal("caller's dns resolver was not used")
+	}
+}
@@ -0,0 +1,174 @@
+package rgrpc
+
+import (
+	"context"
+	"math/rand/v2"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// retrier decides whether and when a failed unary attempt is retried.
+type retrier struct {
+	policy  retryPolicy
+	methods map[string]retryPolicy
+	budget  *retryBudget
+}
+
+// retryPolicy is a RetryPolicy with defaults applied and codes as a set.
+type retryPolicy struct {
+	maxAttempts int
+	codes       map[codes.Code]bool
+	initial     time.Duration
+	max         time.Duration
+	multiplier  float64
+	jitter      float64
+}
+
+func newRetryPolicy(p RetryPolicy) retryPolicy {
+	p = p.withDefaults()
+	rp := retryPolicy{
+		maxAttempts: p.MaxAttempts,
+		codes:       make(map[codes.Code]bool, len(p.RetryableCodes)),
+		initial:     p.InitialBackoff,
+		max:         p.MaxBackoff,
+		multiplier:  p.BackoffMultiplier,
+		jitter:      p.Jitter,
+	}
+	for _, c := range p.RetryableCodes {
+		rp.codes[c] = true
+	}
+	return rp
+}
+
+// newRetrier returns nil when no policy allows a retry.
+func newRetrier(cfg RetryConfig) *retrier {
+	enabled := cfg.Policy.MaxAttempts > 1
+	for _, p := range cfg.MethodPolicies {
+		enabled = enabled || p.MaxAttempts > 1
+	}
+	if !enabled {
+		return nil
+	}
+
+	r := &retrier{
+		policy:  newRetryPolicy(cfg.Policy),
+		methods: make(map[string]retryPolicy, len(cfg.MethodPolicies)),
+		budget:  newRetryBudget(cfg.Budget),
+	}
+	for k, p := range cfg.MethodPolicies {
+		r.methods[k] = newRetryPolicy(p)
+	}
+	return r
+}
+
+// policyFor returns the policy of method ("/pkg.Service/Method"): its own
+// entry, else its service's, else the default.
+func (r *retrier) policyFor(method string) retryPolicy {
+	if p, ok := r.methods[method]; ok {
+		return p
+	}
+	if i := strings.LastIndexByte(method, '/'); i > 0 {
+		if p, ok := r.methods[method[:i+1]]; ok {
+			return p
+		}
+	}
+	return r.policy
+}
+
+// retryable reports whether err, returned by attempt (1-based), may be
+// retried under p. It does not consult the budget.
+func (p retryPolicy) retryable(ctx context.Context, attempt int, err error) bool {
+	if err == nil || attempt >= p.maxAttempts || ctx.Err() != nil {
+		return false
+	}
+	return p.codes[status.Code(err)]
+}
+
+// backoff returns the jittered delay before retry n (1 for the first retry).
+func (p retryPolicy) backoff(n int) time.Duration {
+	d := float64(p.initial)
+	for i := 1; i < n && d < float64(p.max); i++ {
+		d *= p.multiplier
+	}
+	d = min(d, float64(p.max))
+	if p.jitter > 0 {
+		d *= 1 + p.jitter*(2*rand.Float64()-1)
+	}
+	return time.Duration(d)
+}
+
+// sleep waits for d or until ctx is done, and reports whether d elapsed.
+func sleep(ctx context.Context, d time.Duration) bool {
+	if d <= 0 {
+		return ctx.Err() == nil
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// retryBudget is the token bucket described by RetryBudget.
+type retryBudget struct {
+	ratio float64
+	max   float64
+
+	mu     sync.Mutex
+	tokens float64
+}
+
+func newRetryBudget(b RetryBudget) *retryBudget {
+	b = b.withDefaults()
+	return &retryBudget{ratio: b.Ratio, max: b.MaxTokens, tokens: b.MaxTokens}
+}
+
+// deposit credits one call.
+func (b *retryBudget) deposit() {
+	b.mu.Lock()
+	b.tokens = min(b.max, b.tokens+b.ratio)
+	b.mu.Unlock()
+}
+
+// withdraw spends one token for a retry, if available.
+func (b *retryBudget) withdraw() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// invokeWithRetry runs invoker until it succeeds, fails with a code the
+// method's policy does not retry, runs out of attempts or budget, or ctx ends
+// during a backoff. All attempts share ctx, so they report to the same
+// callState and each gets its own stats.Begin/End and attempt_* metrics.
+func (h *hooks) invokeWithRetry(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	p := h.retry.policyFor(method)
+	h.retry.budget.deposit()
+
+	for attempt := 1; ; attempt++ {
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		if !p.retryable(ctx, attempt, err) {
+			return err
+		}
+		if !h.retry.budget.withdraw() {
+			h.metrics.recordRetryThrottled(ctx, method)
+			return err
+		}
+		if !sleep(ctx, p.backoff(attempt)) {
+			return err
+		}
+		h.metrics.recordRetry(ctx, method, status.Code(err))
+	}
+}
@@ -0,0 +1,172 @@
+package rgrpc
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+// startFlakyHealthServer starts a health server whose first failures calls
+// fail with code, and returns its address and a call counter.
+func startFlakyHealthServer(t *testing.T, failures int64, code codes.Code) (string, *atomic.Int64) {
+	t.Helper()
+	var calls atomic.Int64
+	addr := startHealthServerWith(t, "127.0.0.1", func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if calls.Add(1) <= failures {
+			return nil, status.Error(code, "flaky")
+		}
+		return handler(ctx, req)
+	})
+	return addr, &calls
+}
+
+func newRetryTestClient(t *testing.T, addr string, reader sdkmetric.Reader, opts ...Option) healthpb.HealthClient {
+	t.Helper()
+	opts = append([]Option{
+		WithTCPSampling(0),
+		WithMeterProvider(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))),
+		WithDialOptions(grpc.WithTransportCredentials(insecure.NewCredentials())),
+	}, opts...)
+	cc, err := New(context.Background(), "passthrough:///"+addr, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { cc.Close() })
+	return healthpb.NewHealthClient(cc)
+}
+
+// TestRetryPolicy verifies that retryable failures are retried until success
+// and that every attempt is recorded with its attempt_type.
+func TestRetryPolicy(t *testing.T) {
+	addr, calls := startFlakyHealthServer(t, 2, codes.Unavailable)
+	reader := sdkmetric.NewManualReader()
+	client := newRetryTestClient(t, addr, reader,
+		WithRetryPolicy(RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond}),
+	)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if _, err := client.Check(ctx, &healthpb.HealthCheckRequest{}); err != nil {
+		t.Fatalf("call failed despite retries: %v", err)
+	}
+	if got := calls.Load(); got != 3 {
+		t.Errorf("server saw %d calls, want 3", got)
+	}
+	if got := int64Value(t, reader, "rgrpc.retries"); got != 2 {
+		t.Errorf("retries = %d, want 2", got)
+	}
+
+	want := map[[2]string]bool{
+		{attemptInitial, "UNAVAILABLE"}: true,
+		{attemptRetry, "UNAVAILABLE"}:   true,
+		{attemptRetry, "OK"}:            true,
+	}
+	attrs := seriesAttrs(t, reader, "rgrpc.attempt_duration_ms")
+	if len(attrs) != len(want) {
+		t.Fatalf("attempt_duration_ms: got %d series, want %d", len(attrs), len(want))
+	}
+	for _, set := range attrs {
+		typ, _ := set.Value(attribute.Key("attempt_type"))
+		code, _ := set.Value(attribute.Key("grpc_code"))
+		if !want[[2]string{typ.AsString(), code.AsString()}] {
+			t.Errorf("unexpected attempt series %s/%s", typ.AsString(), code.AsString())
+		}
+	}
+}
+
+// TestAttemptMetricsOptIn verifies that clients without retries or hedging
+// record attempt_* histograms only with EnableAttemptMetrics.
+func TestAttemptMetricsOptIn(t *testing.T) {
+	for _, enabled := range []bool{false, true} {
+		addr, _ := startFlakyHealthServer(t, 0, codes.OK)
+		reader := sdkmetric.NewManualReader()
+		client := newRetryTestClient(t, addr, reader, WithAttemptMetrics(enabled))
+		if _, err := client.Check(context.Background(), &healthpb.HealthCheckRequest{}); err != nil {
+			t.Fatal(err)
+		}
+		if got := len(seriesAttrs(t, reader, "rgrpc.attempt_duration_ms")); got != 0 != enabled {
+			t.Errorf("EnableAttemptMetrics=%v: %d attempt_duration_ms series", enabled, got)
+		}
+	}
+}
+
+// TestRetryNonRetryableCode verifies that codes outside RetryableCodes fail
+// on the first attempt.
+func TestRetryNonRetryableCode(t *testing.T) {
+	addr, calls := startFlakyHealthServer(t, 1, codes.InvalidArgument)
+	client := newRetryTestClient(t, addr, sdkmetric.NewManualReader(),
+		WithRetryPolicy(RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond}),
+	)
+
+	_, err := client.Check(context.Background(), &healthpb.HealthCheckRequest{})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("err = %v, want InvalidArgument", err)
+	}
+	if got := calls.Load(); got != 1 {
+		t.Errorf("server saw %d calls, want 1", got)
+	}
+}
+
+// TestRetryBudget verifies that retries stop once the budget is spent.
+func TestRetryBudget(t *testing.T) {
+	addr, _ := startFlakyHealthServer(t, 1<<30, codes.Unavailable)
+	reader := sdkmetric.NewManualReader()
+	client := newRetryTestClient(t, addr, reader,
+		WithRetryPolicy(RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond}),
+		WithRetryBudget(RetryBudget{MaxTokens: 1}),
+	)
+
+	for i := 0; i < 2; i++ {
+		if _, err := client.Check(context.Background(), &healthpb.HealthCheckRequest{}); status.Code(err) != codes.Unavailable {
+			t.Fatalf("err = %v, want Unavailable", err)
+		}
+	}
+	// The first call spends the only token; every further retry is throttled.
+	if got := int64Value(t, reader, "rgrpc.retries"); got != 1 {
+		t.Errorf("retries = %d, want 1", got)
+	}
+	if got := int64Value(t, reader, "rgrpc.retries_throttled"); got != 2 {
+		t.Errorf("retries_throttled = %d, want 2", got)
+	}
+}
+
+func TestRetryPolicyLookupAndBackoff(t *testing.T) {
+	r := newRetrier(RetryConfig{
+		Policy: RetryPolicy{MaxAttempts: 2},
+		MethodPolicies: map[string]RetryPolicy{
+			"/svc.A/":    {MaxAttempts: 4},
+			"/svc.A/Get": {MaxAttempts: 1},
+		},
+	})
+	if got := r.policyFor("/svc.A/Get").maxAttempts; got != 1 {
+		t.Errorf("method entry: maxAttempts = %d, want 1", got)
+	}
+	if got := r.policyFor("/svc.A/List").maxAttempts; got != 4 {
+		t.Errorf("service entry: maxAttempts = %d, want 4", got)
+	}
+	if got := r.policyFor("/svc.B/Get").maxAttempts; got != 2 {
+		t.Errorf("default: maxAttempts = %d, want 2", got)
+	}
+
+	p := newRetryPolicy(RetryPolicy{MaxAttempts: 5, InitialBackoff: 100 * time.Millisecond, MaxBackoff: 300 * time.Millisecond, Jitter: 0.5})
+	for n, base := range map[int]time.Duration{1: 100 * time.Millisecond, 2: 200 * time.Millisecond, 5: 300 * time.Millisecond} {
+		for i := 0; i < 100; i++ {
+			if d := p.backoff(n); d < base/2 || d > base*3/2 {
+				t.Fatalf("backoff(%d) = %v, want within 50%% of %v", n, d, base)
+			}
+		}
+	}
+
+	if newRetrier(RetryConfig{}) != nil {
+		t.Error("expected nil retrier when no policy retries")
+	}
+}
@@ -0,0 +1,97 @@
+package rgrpc
+
+import (
+	"context"
+	"log/slog"
+	"math/rand/v2"
+	"time"
+
+	"golang.org/x/time/rate"
+	"google.golang.org/grpc/codes"
+)
+
+// Reasons a slow-call record is not logged (reason attribute of
+// slow_call_logs_suppressed).
+const (
+	suppressSampled     = "sampled"
+	suppressRateLimited = "rate_limited"
+)
+
+// slowCallLogger writes one structured record per slow call. Sampling and the
+// rate limit are checked before any attribute is built, so a burst of slow
+// calls costs little more than the limiter.
+type slowCallLogger struct {
+	logger     *slog.Logger
+	sampleRate float64
+	lim        *rate.Limiter
+	met        *metrics
+}
+
+// newSlowCallLogger returns nil when cfg has no Logger; callers check for nil.
+func newSlowCallLogger(cfg SlowCallLogConfig, met *metrics) *slowCallLogger {
+	if cfg.Logger == nil {
+		return nil
+	}
+	cfg = cfg.withDefaults()
+	return &slowCallLogger{
+		logger:     cfg.Logger,
+		sampleRate: cfg.SampleRate,
+		lim:        rate.NewLimiter(rate.Limit(cfg.RatePerSec), cfg.Burst),
+		met:        met,
+	}
+}
+
+// log records a slow call of the client for target that exceeded limit. ctx
+// carries the call span, so handlers that read trace context from it see the
+// call's trace.
+func (l *slowCallLogger) log(ctx context.Context, target string, st *callState, code codes.Code, errClass string, callErr error, p callPhases, limit time.Duration) {
+	if l.sampleRate < 1 && rand.Float64() >= l.sampleRate {
+		l.met.recordSlowCallLogSuppressed(ctx, suppressSampled)
+		return
+	}
+	if !l.lim.Allow() {
+		l.met.recordSlowCallLogSuppressed(ctx, suppressRateLimited)
+		return
+	}
+	if !l.logger.Enabled(ctx, slog.LevelWarn) {
+		return
+	}
+
+	attrs := make([]slog.Attr, 0, 18)
+	attrs = append(attrs,
+		slog.String("target", target),
+		slog.String("method", st.method),
+	)
+	if ra := st.remoteTCP.Load(); ra != nil {
+		attrs = append(attrs, slog.String("remote_addr", ra.String()))
+	} else if ip := st.getRemoteIP(); ip != "" {
+		attrs = append(attrs, slog.String("remote_ip", ip))
+	}
+	if la := st.localTCP.Load(); la != nil {
+		attrs = append(attrs, slog.String("local_addr", la.String()))
+	}
+	attrs = append(attrs,
+		slog.Bool("streaming", st.isStreaming),
+		slog.Int("attempts", int(p.attempts)),
+		slog.String("grpc_code", grpcCodeName(code)),
+		slog.String("error_class", errClass),
+	)
+	if callErr != nil {
+		attrs = append(attrs, slog.String("error", callErr.Error()))
+	}
+	attrs = append(attrs,
+		slog.Float64("total_ms", durMs(p.total)),
+		slog.Float64("threshold_ms", durMs(limit)),
+		slog.Float64("pick_wait_ms", durMs(p.pickWait)),
+		slog.Bool("pick_blocked", p.pickBlocked),
+		slog.Float64("stream_establish_ms", durMs(p.streamEstablish)),
+		slog.Float64("send_stall_ms", durMs(p.sendStall)),
+		slog.Float64("response_wait_ms", durMs(p.responseWait)),
+	)
+	if st.span != nil {
+		if sc := st.span.SpanContext(); sc.HasTraceID() {
+			attrs = append(attrs, slog.String("trace_id", sc.TraceID().String()))
+		}
+	}
+	l.logger.LogAttrs(ctx, slog.LevelWarn, "rgrpc: slow call", attrs...)
+}
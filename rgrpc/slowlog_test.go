@@ -0,0 +1,60 @@
+package rgrpc
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+)
+
+// TestSlowCallLog verifies that a slow call logs one record with its phase
+// breakdown, and that records beyond the burst are suppressed and counted.
+func TestSlowCallLog(t *testing.T) {
+	var buf bytes.Buffer
+	reader := sdkmetric.NewManualReader()
+	cfg := DefaultConfig()
+	cfg.TCPMetricsInterval = 0
+	cfg.MeterProvider = sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+	cfg.SlowCall.Threshold = time.Nanosecond // every call is slow
+	cfg.SlowCallLog = SlowCallLogConfig{
+		Logger:     slog.New(slog.NewJSONHandler(&buf, nil)),
+		RatePerSec: 0.001,
+		Burst:      1,
+	}
+	h := mustNewHooks(t, cfg)
+	defer h.close()
+	h.target = "dns:///svc"
+
+	for i := 0; i < 3; i++ {
+		emitUnaryCall(t, h, "/svc/Method")
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 1 {
+		t.Fatalf("expected 1 record, got %d:\n%s", len(lines), buf.String())
+	}
+	var rec map[string]any
+	if err := json.Unmarshal([]byte(lines[0]), &rec); err != nil {
+		t.Fatal(err)
+	}
+	if rec["level"] != "WARN" || rec["method"] != "/svc/Method" || rec["target"] != "dns:///svc" || rec["grpc_code"] != "OK" {
+		t.Errorf("unexpected record %v", rec)
+	}
+	for _, k := range []string{"total_ms", "threshold_ms", "pick_wait_ms", "stream_establish_ms", "send_stall_ms", "response_wait_ms", "attempts"} {
+		if _, ok := rec[k]; !ok {
+			t.Errorf("record has no %s: %v", k, rec)
+		}
+	}
+
+	if got := int64Value(t, reader, "rgrpc.slow_call_logs_suppressed"); got != 2 {
+		t.Errorf("slow_call_logs_suppressed = %d, want 2", got)
+	}
+	attrs := seriesAttrs(t, reader, "rgrpc.slow_call_logs_suppressed")
+	if v, _ := attrs[0].Value("reason"); v.AsString() != suppressRateLimited {
+		t.Errorf("reason = %q, want %s", v.AsString(), suppressRateLimited)
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"net"
 	"sync/atomic"
 	"time"
+
+	"go.opentelemetry.io/otel/trace"
 )
 
 type callStateKey struct{}
@@ -22,7 +24,32 @@ type callState struct {
 	inHeaderUnix  atomic.Int64 // first InHeader received
 	inPayloadUnix atomic.Int64 // first InPayload received
 
-	attempts atomic.Uint32
+	// gotTrailer: trailers received (covers trailers-only responses, which carry no InHeader)
+	gotTrailer atomic.Bool
+
+	attempts    atomic.Uint32
+	tagged      atomic.Uint32                // attempts seen by TagRPC, including ones never sent
+	lastAttempt atomic.Pointer[attemptState] // the most recent attempt seen by TagRPC
+
+	// wire bytes of all messages, across attempts
+	bytesSent     atomic.Int64
+	bytesReceived atomic.Int64
+
+	// set by rgrpc's balancer wrapper (balancer.go)
+	pickUnix    atomic.Int64 // first successful pick
+	pickBlocked atomic.Bool  // a pick had to wait for a ready subchannel
+
+	// hedging (hedge.go): a hedgeable call records its first SubConn so that
+	// its hedge, a separate callState with isHedge set, can avoid it.
+	hedgeable bool
+	isHedge   bool
+	subConn   atomic.Pointer[subConnRef]
+	avoid     *subConnRef
+
+	// the client's circuit breakers and outlier detector, for the picker
+	// (nil when disabled)
+	breakers *breakerSet
+	outliers *outlierDetector
 
 	remoteTCP atomic.Pointer[net.TCPAddr]
 	localTCP  atomic.Pointer[net.TCPAddr]
@@ -32,6 +59,25 @@ type callState struct {
 
 	// isStreaming: true for streaming RPCs, false for unary
 	isStreaming bool
+
+	// span is the call span when tracing is enabled (nil otherwise)
+	span trace.Span
+
+	// finalized guards against finalizing twice (e.g. a stream whose creation
+	// failed gets both a stats.End and an error from the streamer).
+	finalized atomic.Bool
+}
+
+// callPhases is the latency breakdown of one call, computed by finalize.
+type callPhases struct {
+	total           time.Duration
+	pickWait        time.Duration
+	streamEstablish time.Duration
+	sendStall       time.Duration
+	responseWait    time.Duration
+
+	attempts    uint32
+	pickBlocked bool
 }
 
 func (s *callState) reset() {
@@ -42,11 +88,26 @@ func (s *callState) reset() {
 	s.endUnix.Store(0)
 	s.inHeaderUnix.Store(0)
 	s.inPayloadUnix.Store(0)
+	s.gotTrailer.Store(false)
 	s.attempts.Store(0)
+	s.tagged.Store(0)
+	s.lastAttempt.Store(nil)
+	s.bytesSent.Store(0)
+	s.bytesReceived.Store(0)
+	s.pickUnix.Store(0)
+	s.pickBlocked.Store(false)
+	s.hedgeable = false
+	s.isHedge = false
+	s.subConn.Store(nil)
+	s.avoid = nil
+	s.breakers = nil
+	s.outliers = nil
 	s.remoteTCP.Store(nil)
 	s.localTCP.Store(nil)
 	s.remoteIP.Store("") // ok; atomic.Value requires same concrete type after first store; we always store string
 	s.isStreaming = false
+	s.span = nil
+	s.finalized.Store(false)
 }
 
 func (s *callState) setRemoteIPOnce(ip string) {
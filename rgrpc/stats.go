@@ -3,6 +3,7 @@ package rgrpc
 import (
 	"context"
 	"net"
+	"sync/atomic"
 	"time"
 
 	"google.golang.org/grpc/stats"
@@ -17,8 +18,22 @@ func newStatsHandler(h *hooks) stats.Handler {
 }
 
 func (s *statsHandler) TagRPC(ctx context.Context, info *stats.RPCTagInfo) context.Context {
-	// Later: DNS/pick splitting lives here + resolver wrapper.
+	// Resolution and pick are measured by the resolver and balancer wrappers
+	// (resolver.go, balancer.go).
 	_ = info
+
+	// TagRPC runs once per attempt; give each attempt its own phase
+	// timestamps and, with tracing, its own child span.
+	st, _ := ctx.Value(callStateKey{}).(*callState)
+	if st == nil {
+		return ctx
+	}
+	a := newAttemptState(st)
+	st.lastAttempt.Store(a)
+	ctx = context.WithValue(ctx, attemptStateKey{}, a)
+	if s.h.tracing != nil && st.span != nil {
+		ctx = s.h.tracing.startAttempt(ctx, st)
+	}
 	return ctx
 }
 
@@ -27,6 +42,7 @@ func (s *statsHandler) HandleRPC(ctx context.Context, rs stats.RPCStats) {
 	if st == nil {
 		return
 	}
+	a := attemptStateFrom(ctx)
 
 	switch ev := rs.(type) {
 	case *stats.OutHeader:
@@ -34,7 +50,14 @@ func (s *statsHandler) HandleRPC(ctx context.Context, rs stats.RPCStats) {
 		if st.outHeaderUnix.Load() == 0 {
 			st.outHeaderUnix.Store(now)
 		}
-		st.attempts.Add(1)
+		if a != nil {
+			a.outHeaderUnix.CompareAndSwap(0, now)
+			a.remoteIP.Store(ipStringFromAddr(ev.RemoteAddr))
+		}
+		attempt := st.attempts.Add(1)
+		if s.h.tracing != nil && st.span != nil {
+			s.h.tracing.onOutHeader(ctx, attempt, ev.RemoteAddr)
+		}
 
 		if ra, ok := ev.RemoteAddr.(*net.TCPAddr); ok {
 			if st.remoteTCP.Load() == nil {
@@ -56,6 +79,10 @@ func (s *statsHandler) HandleRPC(ctx context.Context, rs stats.RPCStats) {
 		if st.outPayloadUnix.Load() == 0 {
 			st.outPayloadUnix.Store(t.UnixNano())
 		}
+		if a != nil {
+			a.outPayloadUnix.CompareAndSwap(0, t.UnixNano())
+		}
+		st.bytesSent.Add(int64(ev.WireLength))
 
 	case *stats.InHeader:
 		// Track first response header (TTFB start)
@@ -63,6 +90,15 @@ func (s *statsHandler) HandleRPC(ctx context.Context, rs stats.RPCStats) {
 		if st.inHeaderUnix.Load() == 0 {
 			st.inHeaderUnix.Store(now)
 		}
+		if a != nil {
+			a.responded.Store(true)
+		}
+
+	case *stats.InTrailer:
+		st.gotTrailer.Store(true)
+		if a != nil {
+			a.responded.Store(true)
+		}
 
 	case *stats.InPayload:
 		// Track first response payload (TTFB)
@@ -73,6 +109,10 @@ func (s *statsHandler) HandleRPC(ctx context.Context, rs stats.RPCStats) {
 		if st.inPayloadUnix.Load() == 0 {
 			st.inPayloadUnix.Store(t.UnixNano())
 		}
+		if a != nil {
+			a.inPayloadUnix.CompareAndSwap(0, t.UnixNano())
+		}
+		st.bytesReceived.Add(int64(ev.WireLength))
 
 	case *stats.End:
 		t := ev.EndTime
@@ -80,21 +120,71 @@ func (s *statsHandler) HandleRPC(ctx context.Context, rs stats.RPCStats) {
 			t = time.Now()
 		}
 		st.endUnix.Store(t.UnixNano())
+		if a != nil {
+			s.h.endAttempt(ctx, st, a, ev, t.UnixNano())
+		}
+
+		if s.h.tracing != nil && st.span != nil {
+			s.h.tracing.endAttempt(ctx, ev)
+		}
 
 		// For streaming RPCs, finalize here when the stream ends.
 		// This ensures we handle all cases correctly, including client-streaming
 		// where RecvMsg returns nil on success without calling RecvMsg again.
 		// For unary RPCs, the interceptor handles finalization after invoker returns.
 		if st.isStreaming {
-			s.h.finalize(ctx, st, nil)
-			s.h.pool.Put(st)
+			s.h.finalize(ctx, st, ev.Error)
 		}
 	}
 }
 
+type connTagKey struct{}
+
+// connTag carries what TagConn learned about a connection to HandleConn.
+type connTag struct {
+	remoteIP string
+	ci       *connInfo // nil if the conn was not created by hooks.dial
+
+	beginUnix atomic.Int64
+}
+
+// TagConn joins the HTTP/2 connection with the connRegistry entry created when
+// the dialer returned it. The entry is captured here because trackedConn.Close
+// removes it from the registry before ConnEnd is delivered.
 func (s *statsHandler) TagConn(ctx context.Context, info *stats.ConnTagInfo) context.Context {
-	return ctx
+	tag := &connTag{remoteIP: ipStringFromAddr(info.RemoteAddr)}
+	if info.LocalAddr != nil && info.RemoteAddr != nil {
+		tag.ci = s.h.reg.get(info.LocalAddr.String(), info.RemoteAddr.String())
+	}
+	if tag.ci != nil {
+		tag.remoteIP = tag.ci.remoteIP
+	}
+	if tag.remoteIP == "" {
+		tag.remoteIP = "unknown"
+	}
+	return context.WithValue(ctx, connTagKey{}, tag)
+}
+
+func (s *statsHandler) HandleConn(ctx context.Context, cs stats.ConnStats) {
+	tag, _ := ctx.Value(connTagKey{}).(*connTag)
+	if tag == nil {
+		return
+	}
+
+	switch cs.(type) {
+	case *stats.ConnBegin:
+		tag.beginUnix.Store(unixNow())
+		s.h.metrics.recordConnBegin(ctx, tag.remoteIP)
+
+	case *stats.ConnEnd:
+		// Lifetime starts at TCP connect when the registry entry is known, so
+		// it includes the TLS handshake and HTTP/2 preface.
+		start := tag.beginUnix.Load()
+		if tag.ci != nil {
+			start = tag.ci.createdUnix
+		}
+		s.h.metrics.recordConnEnd(ctx, tag.remoteIP, time.Since(time.Unix(0, start)))
+	}
 }
-func (s *statsHandler) HandleConn(ctx context.Context, cs stats.ConnStats) {}
 
 var _ stats.Handler = (*statsHandler)(nil)
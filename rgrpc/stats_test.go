@@ -0,0 +1,106 @@
+package rgrpc
+
+import (
+	"context"
+	"runtime"
+	"testing"
+	"time"
+
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// TestConnectionLifecycle verifies that ConnBegin/ConnEnd drive the opened,
+// closed and active connection metrics and the lifetime histogram, labeled
+// with the remote IP from the connRegistry entry.
+func TestConnectionLifecycle(t *testing.T) {
+	addr := startHealthServer(t, nil)
+	reader := sdkmetric.NewManualReader()
+	cc, err := New(context.Background(), "passthrough:///"+addr,
+		WithTCPSampling(0),
+		WithMeterProvider(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))),
+		WithDialOptions(grpc.WithTransportCredentials(insecure.NewCredentials())),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if _, err := healthpb.NewHealthClient(cc).Check(ctx, &healthpb.HealthCheckRequest{}); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := int64Value(t, reader, "rgrpc.connections_opened"); got != 1 {
+		t.Errorf("connections_opened = %d, want 1", got)
+	}
+	if got := int64Value(t, reader, "rgrpc.connections_active"); got != 1 {
+		t.Errorf("connections_active = %d, want 1", got)
+	}
+
+	cc.Close()
+	// ConnEnd is delivered when the transport finishes closing.
+	for deadline := time.Now().Add(5 * time.Second); len(seriesAttrs(t, reader, "rgrpc.connection_lifetime_s")) == 0; {
+		if time.Now().After(deadline) {
+			t.Fatal("connection_lifetime_s not recorded after Close")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if got := int64Value(t, reader, "rgrpc.connections_closed"); got != 1 {
+		t.Errorf("connections_closed = %d, want 1", got)
+	}
+	if got := int64Value(t, reader, "rgrpc.connections_active"); got != 0 {
+		t.Errorf("connections_active = %d, want 0", got)
+	}
+	attrs := seriesAttrs(t, reader, "rgrpc.connection_lifetime_s")
+	if v, _ := attrs[0].Value("remote_ip"); v.AsString() != "127.0.0.1" {
+		t.Errorf("remote_ip = %q, want 127.0.0.1", v.AsString())
+	}
+}
+
+// TestConnectionSummary verifies that closing a connection takes a final
+// TCP_INFO snapshot, records it and passes it to the user callback.
+func TestConnectionSummary(t *testing.T) {
+	addr := startHealthServer(t, nil)
+	reader := sdkmetric.NewManualReader()
+	summaries := make(chan ConnectionSummary, 1)
+	cc, err := New(context.Background(), "passthrough:///"+addr,
+		WithTCPSampling(0), // the periodic sampler never sees this connection
+		WithConnectionClosedCallback(func(s ConnectionSummary) { summaries <- s }),
+		WithMeterProvider(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))),
+		WithDialOptions(grpc.WithTransportCredentials(insecure.NewCredentials())),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if _, err := healthpb.NewHealthClient(cc).Check(ctx, &healthpb.HealthCheckRequest{}); err != nil {
+		t.Fatal(err)
+	}
+	cc.Close()
+
+	var sum ConnectionSummary
+	select {
+	case sum = <-summaries:
+	case <-ctx.Done():
+		t.Fatal("OnConnectionClosed not called")
+	}
+	if sum.RemoteAddr != addr || sum.RemoteIP != "127.0.0.1" || sum.Lifetime <= 0 {
+		t.Errorf("unexpected summary %+v", sum)
+	}
+
+	if runtime.GOOS != "linux" {
+		return
+	}
+	if !sum.TCP.Available || sum.TCP.BytesSent == 0 || sum.TCP.BytesAcked == 0 {
+		t.Errorf("expected a final TCP_INFO snapshot, got %+v", sum.TCP)
+	}
+	if got := len(seriesAttrs(t, reader, "rgrpc.conn_bytes_sent")); got != 1 {
+		t.Errorf("conn_bytes_sent: expected 1 series, got %d", got)
+	}
+}
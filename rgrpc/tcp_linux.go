@@ -7,14 +7,13 @@ import (
 	"time"
 
 	"golang.org/x/sys/unix"
-	"net"
 )
 
 type tcpTracker struct {
 	raw syscall.RawConn
 }
 
-func newTCPTracker(c *net.TCPConn) (*tcpTracker, error) {
+func newTCPTracker(c syscall.Conn) (*tcpTracker, error) {
 	rc, err := c.SyscallConn()
 	if err != nil {
 		return nil, err
@@ -34,14 +33,43 @@ func (t *tcpTracker) Sample() (TCPInfoSummary, bool) {
 	if controlErr != nil || err != nil || info == nil {
 		return TCPInfoSummary{Available: false}, false
 	}
+	return summaryFromTCPInfo(info), true
+}
 
-	// Linux TCP_INFO rtt is in usec.
-	rtt := time.Duration(info.Rtt) * time.Microsecond
+// summaryFromTCPInfo converts a kernel struct tcp_info, from getsockopt or a
+// sock_diag dump.
+func summaryFromTCPInfo(info *unix.TCPInfo) TCPInfoSummary {
+	// Linux TCP_INFO times are in usec. Fields newer than the running kernel
+	// are left zero.
+	usec := func(v uint64) time.Duration { return time.Duration(v) * time.Microsecond }
 
 	return TCPInfoSummary{
-		Available:    true,
-		RTT:          rtt,
-		SndCwnd:      info.Snd_cwnd,
+		Available: true,
+
+		RTT:    usec(uint64(info.Rtt)),
+		RTTVar: usec(uint64(info.Rttvar)),
+		MinRTT: usec(uint64(info.Min_rtt)),
+
+		SndCwnd:     info.Snd_cwnd,
+		SndSsthresh: info.Snd_ssthresh,
+
 		TotalRetrans: info.Total_retrans,
-	}, true
+		Unacked:      info.Unacked,
+		Lost:         info.Lost,
+		Retrans:      info.Retrans,
+		Reordering:   info.Reordering,
+
+		RcvSpace:     info.Rcv_space,
+		NotsentBytes: info.Notsent_bytes,
+
+		DeliveryRate: info.Delivery_rate,
+		PacingRate:   info.Pacing_rate,
+
+		BusyTime:      usec(info.Busy_time),
+		RwndLimited:   usec(info.Rwnd_limited),
+		SndbufLimited: usec(info.Sndbuf_limited),
+
+		BytesSent:  info.Bytes_sent,
+		BytesAcked: info.Bytes_acked,
+	}
 }
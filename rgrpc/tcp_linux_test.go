@@ -0,0 +1,334 @@
+//go:build linux
+
+package rgrpc
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/resolver"
+	"google.golang.org/grpc/resolver/manual"
+)
+
+// TestTCPInfoMetrics verifies that a diag sample emits the expanded TCP_INFO
+// histograms and the limited-time counters.
+func TestTCPInfoMetrics(t *testing.T) {
+	addr := startHealthServer(t, nil)
+	reader := sdkmetric.NewManualReader()
+	cc, err := New(context.Background(), "passthrough:///"+addr,
+		WithTCPSampling(0),
+		WithMeterProvider(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))),
+		WithDialOptions(grpc.WithTransportCredentials(insecure.NewCredentials())),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cc.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if _, err := healthpb.NewHealthClient(cc).Check(ctx, &healthpb.HealthCheckRequest{}); err != nil {
+		t.Fatal(err)
+	}
+
+	conns := cc.hooks.reg.snapshot()
+	if len(conns) != 1 || conns[0].tracker == nil {
+		t.Fatalf("expected one tracked TCP connection, got %d", len(conns))
+	}
+	cc.hooks.diag.enqueuePeriodic(conns[0])
+
+	for deadline := time.Now().Add(5 * time.Second); len(seriesAttrs(t, reader, "rgrpc.tcp_min_rtt_ms")) == 0; {
+		if time.Now().After(deadline) {
+			t.Fatal("tcp_min_rtt_ms not recorded")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	for _, name := range []string{
+		"rgrpc.tcp_rttvar_ms", "rgrpc.tcp_unacked", "rgrpc.tcp_delivery_rate", "rgrpc.tcp_rcv_space",
+	} {
+		if got := len(seriesAttrs(t, reader, name)); got != 1 {
+			t.Errorf("%s: expected 1 series, got %d", name, got)
+		}
+	}
+	names := collectMetricNames(t, reader)
+	for _, name := range []string{"rgrpc.tcp_busy_time_ms", "rgrpc.tcp_rwnd_limited_ms", "rgrpc.tcp_sndbuf_limited_ms"} {
+		if !names[name] {
+			t.Errorf("%s not recorded", name)
+		}
+	}
+}
+
+// TestTCPPacingRateSkipsUnset verifies that unset (0) and unlimited (~0)
+// pacing rates are not recorded, like snd_ssthresh during slow start.
+func TestTCPPacingRateSkipsUnset(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	cfg := DefaultConfig()
+	cfg.MeterProvider = sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+	m, err := newMetrics(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, rate := range []uint64{0, tcpUnlimitedPacing} {
+		m.recordTCP(context.Background(), "10.0.0.1", "periodic", TCPInfoSummary{
+			Available: true, SndSsthresh: tcpInfiniteSsthresh, PacingRate: rate,
+		}, tcpDeltas{})
+	}
+	names := collectMetricNames(t, reader)
+	if names["rgrpc.tcp_pacing_rate"] || names["rgrpc.tcp_snd_ssthresh"] {
+		t.Fatal("unset pacing rate or infinite ssthresh recorded")
+	}
+
+	m.recordTCP(context.Background(), "10.0.0.1", "periodic", TCPInfoSummary{
+		Available: true, PacingRate: 1 << 20,
+	}, tcpDeltas{})
+	if got := len(seriesAttrs(t, reader, "rgrpc.tcp_pacing_rate")); got != 1 {
+		t.Fatalf("tcp_pacing_rate: expected 1 series, got %d", got)
+	}
+}
+
+func TestConnInfoAdvance(t *testing.T) {
+	ci := &connInfo{}
+	ci.advance(TCPInfoSummary{TotalRetrans: 3, BusyTime: time.Second, RwndLimited: 100 * time.Millisecond})
+	d := ci.advance(TCPInfoSummary{TotalRetrans: 5, BusyTime: 3 * time.Second, RwndLimited: 600 * time.Millisecond})
+	want := tcpDeltas{retrans: 2, busy: 2 * time.Second, rwndLimited: 500 * time.Millisecond}
+	if d != want {
+		t.Errorf("advance = %+v, want %+v", d, want)
+	}
+}
+
+// TestSlowCallTriggersTCPSample verifies that a slow call samples its own
+// connection, tagged trigger=slow_call, without periodic sampling.
+func TestSlowCallTriggersTCPSample(t *testing.T) {
+	addr := startHealthServer(t, nil)
+	reader := sdkmetric.NewManualReader()
+	cc, err := New(context.Background(), "passthrough:///"+addr,
+		WithTCPSampling(0),
+		WithSlowCallThreshold(time.Nanosecond),
+		WithMeterProvider(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))),
+		WithDialOptions(grpc.WithTransportCredentials(insecure.NewCredentials())),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cc.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if _, err := healthpb.NewHealthClient(cc).Check(ctx, &healthpb.HealthCheckRequest{}); err != nil {
+		t.Fatal(err)
+	}
+
+	var attrs []attribute.Set
+	for deadline := time.Now().Add(5 * time.Second); len(attrs) == 0; attrs = seriesAttrs(t, reader, "rgrpc.tcp_rtt_ms") {
+		if time.Now().After(deadline) {
+			t.Fatal("tcp_rtt_ms not recorded for slow call")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if v, _ := attrs[0].Value("trigger"); v.AsString() != triggerSlowCall {
+		t.Errorf("trigger = %q, want %q", v.AsString(), triggerSlowCall)
+	}
+}
+
+// TestTCPSamplerCooldownDrop verifies that a second request within the
+// configured cooldown is dropped and counted with reason=cooldown.
+func TestTCPSamplerCooldownDrop(t *testing.T) {
+	addr := startHealthServer(t, nil)
+	reader := sdkmetric.NewManualReader()
+	cc, err := New(context.Background(), "passthrough:///"+addr,
+		WithTCPSampling(0),
+		WithTCPSampler(TCPSamplerConfig{RatePerSec: 1000, Burst: 10, Cooldown: time.Hour}),
+		WithMeterProvider(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))),
+		WithDialOptions(grpc.WithTransportCredentials(insecure.NewCredentials())),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cc.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if _, err := healthpb.NewHealthClient(cc).Check(ctx, &healthpb.HealthCheckRequest{}); err != nil {
+		t.Fatal(err)
+	}
+
+	ci := cc.hooks.reg.snapshot()[0]
+	cc.hooks.diag.enqueuePeriodic(ci)
+	cc.hooks.diag.enqueuePeriodic(ci)
+
+	for deadline := time.Now().Add(5 * time.Second); len(seriesAttrs(t, reader, "rgrpc.tcp_samples_dropped")) == 0; {
+		if time.Now().After(deadline) {
+			t.Fatal("tcp_samples_dropped not recorded")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if got := int64Value(t, reader, "rgrpc.tcp_samples"); got != 1 {
+		t.Errorf("tcp_samples = %d, want 1", got)
+	}
+	attrs := seriesAttrs(t, reader, "rgrpc.tcp_samples_dropped")
+	if v, _ := attrs[0].Value("reason"); v.AsString() != dropCooldown {
+		t.Errorf("reason = %q, want %q", v.AsString(), dropCooldown)
+	}
+}
+
+// TestTCPSamplerUnlimited verifies that a negative rate and cooldown let every
+// request for the same connection through.
+func TestTCPSamplerUnlimited(t *testing.T) {
+	addr := startHealthServer(t, nil)
+	reader := sdkmetric.NewManualReader()
+	cc, err := New(context.Background(), "passthrough:///"+addr,
+		WithTCPSampling(0),
+		WithTCPSampler(TCPSamplerConfig{RatePerSec: -1, Burst: 1, Cooldown: -1}),
+		WithMeterProvider(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))),
+		WithDialOptions(grpc.WithTransportCredentials(insecure.NewCredentials())),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cc.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if _, err := healthpb.NewHealthClient(cc).Check(ctx, &healthpb.HealthCheckRequest{}); err != nil {
+		t.Fatal(err)
+	}
+
+	ci := cc.hooks.reg.snapshot()[0]
+	const n = 5
+	for i := 0; i < n; i++ {
+		cc.hooks.diag.enqueuePeriodic(ci)
+	}
+	for deadline := time.Now().Add(5 * time.Second); len(seriesAttrs(t, reader, "rgrpc.tcp_samples")) == 0 || int64Value(t, reader, "rgrpc.tcp_samples") < n; {
+		if time.Now().After(deadline) {
+			t.Fatalf("tcp_samples did not reach %d", n)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if got := len(seriesAttrs(t, reader, "rgrpc.tcp_samples_dropped")); got != 0 {
+		t.Errorf("tcp_samples_dropped has %d series, want none", got)
+	}
+}
+
+// TestNetlinkSampler verifies that the sock_diag dump finds the client's
+// connection by 4-tuple and that sampleAll records it without the queue.
+func TestNetlinkSampler(t *testing.T) {
+	addr := startHealthServer(t, nil)
+	reader := sdkmetric.NewManualReader()
+	cc, err := New(context.Background(), "passthrough:///"+addr,
+		WithTCPSampling(0),
+		WithTCPSampler(TCPSamplerConfig{Backend: TCPSamplerNetlink, RatePerSec: 1e-9, Burst: 1}),
+		WithMeterProvider(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))),
+		WithDialOptions(grpc.WithTransportCredentials(insecure.NewCredentials())),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cc.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if _, err := healthpb.NewHealthClient(cc).Check(ctx, &healthpb.HealthCheckRequest{}); err != nil {
+		t.Fatal(err)
+	}
+
+	conns := cc.hooks.reg.snapshot()
+	infos, err := netlinkTCPInfo(conns)
+	if err != nil {
+		t.Skipf("sock_diag unavailable: %v", err)
+	}
+	got, ok := infos[cc.hooks.reg.key(conns[0].local, conns[0].remote)]
+	if !ok {
+		t.Fatalf("connection %s->%s not in dump of %d sockets", conns[0].local, conns[0].remote, len(infos))
+	}
+	if !got.Available || got.BytesAcked == 0 {
+		t.Errorf("unexpected TCP_INFO from dump: %+v", got)
+	}
+
+	// The rate limit would allow at most one queued sample; the dump samples
+	// every connection synchronously on each call.
+	cc.hooks.diag.sampleAll(conns)
+	cc.hooks.diag.sampleAll(conns)
+	if n := int64Value(t, reader, "rgrpc.tcp_samples"); n != 2 {
+		t.Errorf("tcp_samples = %d, want 2", n)
+	}
+}
+
+// TestPerConnectionTCPMetrics verifies that each sampled connection gets its own
+// conn_id series, and that a connection's series disappear when it closes
+// while the client and its other connections stay open.
+func TestPerConnectionTCPMetrics(t *testing.T) {
+	keepAddr := startHealthServer(t, nil)
+	dropAddr := startHealthServer(t, nil)
+
+	r := manual.NewBuilderWithScheme("rgrpcconnmetrics")
+	r.InitialState(resolver.State{Addresses: []resolver.Address{{Addr: keepAddr}, {Addr: dropAddr}}})
+
+	reader := sdkmetric.NewManualReader()
+	cc, err := New(context.Background(), "rgrpcconnmetrics:///svc",
+		WithTCPSampling(0),
+		WithClientSideLB(true),
+		WithResolver(r),
+		WithPerConnectionTCPMetrics(),
+		WithMeterProvider(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))),
+		WithDialOptions(grpc.WithTransportCredentials(insecure.NewCredentials())),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cc.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if _, err := healthpb.NewHealthClient(cc).Check(ctx, &healthpb.HealthCheckRequest{}); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := len(seriesAttrs(t, reader, "rgrpc.tcp_conn_rtt_ms")); got != 0 {
+		t.Errorf("tcp_conn_rtt_ms reported %d series before any sample", got)
+	}
+
+	// Round robin connects to both backends.
+	waitFor := func(what string, done func() bool) {
+		t.Helper()
+		for deadline := time.Now().Add(5 * time.Second); !done(); {
+			if time.Now().After(deadline) {
+				t.Fatal(what)
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+	waitFor("two connections not registered", func() bool { return len(cc.hooks.reg.snapshot()) == 2 })
+
+	var keepID string
+	for _, ci := range cc.hooks.reg.snapshot() {
+		if ci.remote == keepAddr {
+			keepID = ci.id
+		}
+		cc.hooks.diag.enqueuePeriodic(ci)
+	}
+	if keepID == "" {
+		t.Fatalf("no connection to %s", keepAddr)
+	}
+	waitFor("tcp_conn_rtt_ms not reported for both connections", func() bool {
+		return len(seriesAttrs(t, reader, "rgrpc.tcp_conn_rtt_ms")) == 2
+	})
+
+	// Drop one backend: its connection closes, the client stays open.
+	r.UpdateState(resolver.State{Addresses: []resolver.Address{{Addr: keepAddr}}})
+	waitFor("dropped connection still registered", func() bool { return len(cc.hooks.reg.snapshot()) == 1 })
+
+	attrs := seriesAttrs(t, reader, "rgrpc.tcp_conn_rtt_ms")
+	if len(attrs) != 1 {
+		t.Fatalf("tcp_conn_rtt_ms reports %d series after one connection closed, want 1", len(attrs))
+	}
+	if v, _ := attrs[0].Value("conn_id"); v.AsString() != keepID {
+		t.Errorf("conn_id = %q, want the open connection's %q", v.AsString(), keepID)
+	}
+}
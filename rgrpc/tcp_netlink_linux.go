@@ -0,0 +1,157 @@
+//go:build linux
+
+package rgrpc
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+	"strconv"
+	"syscall"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// sock_diag constants and layouts from linux/inet_diag.h; x/sys/unix does not
+// define them.
+const (
+	inetDiagInfo = 2 // INET_DIAG_INFO attribute: struct tcp_info
+
+	sizeofInetDiagReqV2 = 56 // family, protocol, ext, pad, states, inet_diag_sockid
+	sizeofInetDiagMsg   = 72 // family, state, timer, retrans, inet_diag_sockid, 5 x u32
+
+	tcpListen = 10 // TCP_LISTEN in the kernel's TCP state enum
+)
+
+// netlinkTCPInfo dumps TCP_INFO for every TCP socket in the network namespace
+// with one NETLINK_SOCK_DIAG request per address family used by conns.
+// Results are keyed like connRegistry: "local->remote".
+func netlinkTCPInfo(conns []*connInfo) (map[string]TCPInfoSummary, error) {
+	var families []uint8
+	var v4, v6 bool
+	for _, ci := range conns {
+		if host, _, err := net.SplitHostPort(ci.local); err == nil {
+			if ip := net.ParseIP(host); ip != nil && ip.To4() != nil {
+				v4 = true
+			} else if ip != nil {
+				v6 = true
+			}
+		}
+	}
+	if v4 {
+		families = append(families, unix.AF_INET)
+	}
+	if v6 {
+		families = append(families, unix.AF_INET6)
+	}
+	if len(families) == 0 {
+		return nil, nil
+	}
+
+	fd, err := unix.Socket(unix.AF_NETLINK, unix.SOCK_DGRAM|unix.SOCK_CLOEXEC, unix.NETLINK_SOCK_DIAG)
+	if err != nil {
+		return nil, fmt.Errorf("sock_diag socket: %w", err)
+	}
+	defer unix.Close(fd)
+
+	if err := unix.Bind(fd, &unix.SockaddrNetlink{Family: unix.AF_NETLINK}); err != nil {
+		return nil, fmt.Errorf("sock_diag bind: %w", err)
+	}
+
+	out := make(map[string]TCPInfoSummary)
+	for i, family := range families {
+		if err := netlinkDump(fd, uint32(i+1), family, out); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+func netlinkDump(fd int, seq uint32, family uint8, out map[string]TCPInfoSummary) error {
+	req := make([]byte, unix.SizeofNlMsghdr+sizeofInetDiagReqV2)
+	ne := binary.NativeEndian
+	ne.PutUint32(req[0:4], uint32(len(req)))
+	ne.PutUint16(req[4:6], unix.SOCK_DIAG_BY_FAMILY)
+	ne.PutUint16(req[6:8], unix.NLM_F_REQUEST|unix.NLM_F_DUMP)
+	ne.PutUint32(req[8:12], seq)
+	body := req[unix.SizeofNlMsghdr:]
+	body[0] = family
+	body[1] = unix.IPPROTO_TCP
+	body[2] = 1 << (inetDiagInfo - 1)
+	ne.PutUint32(body[4:8], ^uint32(1<<tcpListen)) // every state but LISTEN; the id stays zero (no filter)
+
+	if err := unix.Sendto(fd, req, 0, &unix.SockaddrNetlink{Family: unix.AF_NETLINK}); err != nil {
+		return fmt.Errorf("sock_diag send: %w", err)
+	}
+
+	buf := make([]byte, 64<<10)
+	for {
+		n, _, err := unix.Recvfrom(fd, buf, 0)
+		if err != nil {
+			return fmt.Errorf("sock_diag recv: %w", err)
+		}
+		msgs, err := syscall.ParseNetlinkMessage(buf[:n])
+		if err != nil {
+			return fmt.Errorf("sock_diag parse: %w", err)
+		}
+		for _, m := range msgs {
+			if m.Header.Seq != seq {
+				continue
+			}
+			switch m.Header.Type {
+			case unix.NLMSG_DONE:
+				return nil
+			case unix.NLMSG_ERROR:
+				if len(m.Data) >= 4 {
+					if errno := -int32(ne.Uint32(m.Data[:4])); errno != 0 {
+						return fmt.Errorf("sock_diag: %w", unix.Errno(errno))
+					}
+				}
+				return nil
+			case unix.SOCK_DIAG_BY_FAMILY:
+				if key, info, ok := parseInetDiagMsg(m.Data); ok {
+					out[key] = summaryFromTCPInfo(info)
+				}
+			}
+		}
+	}
+}
+
+// parseInetDiagMsg extracts the 4-tuple and the INET_DIAG_INFO attribute of
+// one struct inet_diag_msg.
+func parseInetDiagMsg(b []byte) (string, *unix.TCPInfo, bool) {
+	if len(b) < sizeofInetDiagMsg {
+		return "", nil, false
+	}
+	family := b[0]
+	id := b[4:52]
+	sport := binary.BigEndian.Uint16(id[0:2])
+	dport := binary.BigEndian.Uint16(id[2:4])
+	src, dst := id[4:20], id[20:36]
+	if family == unix.AF_INET {
+		src, dst = src[:4], dst[:4]
+	}
+	key := net.JoinHostPort(net.IP(src).String(), strconv.Itoa(int(sport))) + "->" +
+		net.JoinHostPort(net.IP(dst).String(), strconv.Itoa(int(dport)))
+
+	for attrs := b[sizeofInetDiagMsg:]; len(attrs) >= unix.SizeofRtAttr; {
+		l := int(binary.NativeEndian.Uint16(attrs[0:2]))
+		typ := binary.NativeEndian.Uint16(attrs[2:4])
+		if l < unix.SizeofRtAttr || l > len(attrs) {
+			break
+		}
+		if typ == inetDiagInfo {
+			// Older kernels send a shorter struct; the missing fields stay zero.
+			var info unix.TCPInfo
+			copy(unsafe.Slice((*byte)(unsafe.Pointer(&info)), unsafe.Sizeof(info)), attrs[unix.SizeofRtAttr:l])
+			return key, &info, true
+		}
+		next := (l + unix.RTA_ALIGNTO - 1) &^ (unix.RTA_ALIGNTO - 1)
+		if next > len(attrs) {
+			break
+		}
+		attrs = attrs[next:]
+	}
+	return "", nil, false
+}
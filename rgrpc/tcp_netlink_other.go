@@ -0,0 +1,9 @@
+//go:build !linux
+
+package rgrpc
+
+import "errors"
+
+func netlinkTCPInfo(conns []*connInfo) (map[string]TCPInfoSummary, error) {
+	return nil, errors.New("sock_diag not supported on this platform")
+}
@@ -2,7 +2,8 @@ package rgrpc
 
 import "time"
 
-// startTCPSampler periodically enqueues TCP_INFO sampling for all active conns.
+// startTCPSampler periodically samples TCP_INFO for all active conns: through
+// the diag worker's queue, or with one sock_diag dump (TCPSamplerNetlink).
 // Metrics emitted use the same label scheme as call histograms (remote_ip).
 func startTCPSampler(cfg Config, reg *connRegistry, diag *diagWorker, stopCh <-chan struct{}) {
 	interval := cfg.TCPMetricsInterval
@@ -19,6 +20,10 @@ func startTCPSampler(cfg Config, reg *connRegistry, diag *diagWorker, stopCh <-c
 				return
 			case <-t.C:
 				conns := reg.snapshot()
+				if cfg.TCPSampler.Backend == TCPSamplerNetlink {
+					diag.sampleAll(conns)
+					continue
+				}
 				for _, ci := range conns {
 					diag.enqueuePeriodic(ci)
 				}
@@ -4,12 +4,12 @@ package rgrpc
 
 import (
 	"errors"
-	"net"
+	"syscall"
 )
 
 type tcpTracker struct{}
 
-func newTCPTracker(c *net.TCPConn) (*tcpTracker, error) {
+func newTCPTracker(c syscall.Conn) (*tcpTracker, error) {
 	return nil, errors.New("TCP_INFO not supported on this platform")
 }
 
@@ -0,0 +1,171 @@
+package rgrpc
+
+import (
+	"context"
+	"net"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	otelcodes "go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/stats"
+)
+
+const tracerName = "github.com/subganapathy/resilient-grpc-client/rgrpc"
+
+// tracing creates one client span per RPC and one child span per attempt.
+// Phase timestamps collected in callState are attached as span events when the
+// call is finalized, so a slow histogram bucket can be explained by its trace.
+type tracing struct {
+	tracer trace.Tracer
+	prop   propagation.TextMapPropagator
+}
+
+// newTracing returns nil when tracing is disabled; all call sites check for nil.
+func newTracing(cfg Config) *tracing {
+	if !cfg.EnableTracing {
+		return nil
+	}
+	tp := cfg.TracerProvider
+	if tp == nil {
+		tp = otel.GetTracerProvider()
+	}
+	return &tracing{
+		tracer: tp.Tracer(tracerName),
+		prop:   propagation.TraceContext{},
+	}
+}
+
+// startCall starts the call span and stores it in st. The returned ctx carries
+// the span so attempt spans (and any exemplars) are parented to it.
+func (t *tracing) startCall(ctx context.Context, st *callState) context.Context {
+	name := strings.TrimPrefix(st.method, "/")
+	service, method := splitMethod(name)
+	ctx, span := t.tracer.Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithTimestamp(time.Unix(0, st.startUnix)),
+		trace.WithAttributes(
+			attribute.String("rpc.system", "grpc"),
+			attribute.String("rpc.service", service),
+			attribute.String("rpc.method", method),
+			attribute.Bool("rgrpc.streaming", st.isStreaming),
+		),
+	)
+	st.span = span
+	return ctx
+}
+
+// startAttempt starts a child span for one transport attempt and injects its
+// context into the attempt's outgoing metadata as W3C traceparent/tracestate.
+func (t *tracing) startAttempt(ctx context.Context, st *callState) context.Context {
+	ctx, _ = t.tracer.Start(ctx, "Attempt."+strings.TrimPrefix(st.method, "/"),
+		trace.WithSpanKind(trace.SpanKindClient),
+	)
+
+	md, ok := metadata.FromOutgoingContext(ctx)
+	if ok {
+		md = md.Copy()
+	} else {
+		md = metadata.MD{}
+	}
+	t.prop.Inject(ctx, metadataCarrier(md))
+	return metadata.NewOutgoingContext(ctx, md)
+}
+
+func (t *tracing) onOutHeader(ctx context.Context, attempt uint32, remote net.Addr) {
+	span := trace.SpanFromContext(ctx)
+	attrs := []attribute.KeyValue{attribute.Int("rgrpc.attempt", int(attempt))}
+	if ip := ipStringFromAddr(remote); ip != "" {
+		attrs = append(attrs, attribute.String("network.peer.address", ip))
+	}
+	span.SetAttributes(attrs...)
+}
+
+func (t *tracing) endAttempt(ctx context.Context, ev *stats.End) {
+	span := trace.SpanFromContext(ctx)
+	if ev.Error != nil {
+		span.SetStatus(otelcodes.Error, ev.Error.Error())
+	}
+	end := ev.EndTime
+	if end.IsZero() {
+		end = time.Now()
+	}
+	span.End(trace.WithTimestamp(end))
+}
+
+// endCall attaches the phase timestamps as events and ends the call span.
+func (t *tracing) endCall(st *callState, code codes.Code, errClass string, callErr error) {
+	span := st.span
+	if span == nil {
+		return
+	}
+
+	phases := []struct {
+		name string
+		unix int64
+	}{
+		{"pick", st.pickUnix.Load()},
+		{"out_header", st.outHeaderUnix.Load()},
+		{"out_payload", st.outPayloadUnix.Load()},
+		{"in_header", st.inHeaderUnix.Load()},
+		{"in_payload", st.inPayloadUnix.Load()},
+	}
+	for _, p := range phases {
+		if p.unix > 0 {
+			span.AddEvent(p.name, trace.WithTimestamp(time.Unix(0, p.unix)))
+		}
+	}
+
+	span.SetAttributes(
+		attribute.String("network.peer.address", st.getRemoteIP()),
+		attribute.Int("rgrpc.attempts", int(st.attempts.Load())),
+		attribute.Int("rpc.grpc.status_code", int(code)),
+		attribute.String("rgrpc.error_class", errClass),
+	)
+	if callErr != nil {
+		span.SetStatus(otelcodes.Error, callErr.Error())
+	}
+
+	end := st.endUnix.Load()
+	if end == 0 {
+		end = unixNow()
+	}
+	span.End(trace.WithTimestamp(time.Unix(0, end)))
+}
+
+// splitMethod splits "pkg.Service/Method" into service and method.
+func splitMethod(full string) (string, string) {
+	if i := strings.LastIndexByte(full, '/'); i >= 0 {
+		return full[:i], full[i+1:]
+	}
+	return "", full
+}
+
+// metadataCarrier adapts metadata.MD to propagation.TextMapCarrier.
+type metadataCarrier metadata.MD
+
+func (c metadataCarrier) Get(key string) string {
+	if v := metadata.MD(c).Get(key); len(v) > 0 {
+		return v[0]
+	}
+	return ""
+}
+
+func (c metadataCarrier) Set(key, value string) {
+	metadata.MD(c).Set(key, value)
+}
+
+func (c metadataCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for k := range c {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+var _ propagation.TextMapCarrier = metadataCarrier(nil)
@@ -0,0 +1,117 @@
+package rgrpc
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+)
+
+// startHealthServerWith starts an in-process gRPC server exposing the health
+// service on ip, with interceptor (when non-nil) in front of every call, and
+// returns its address. Tests needing several backend IPs listen on other
+// loopback addresses, and are skipped where those cannot be bound.
+func startHealthServerWith(t *testing.T, ip string, interceptor grpc.UnaryServerInterceptor) string {
+	t.Helper()
+	lis, err := net.Listen("tcp", net.JoinHostPort(ip, "0"))
+	if err != nil {
+		if ip != "127.0.0.1" {
+			t.Skipf("cannot listen on %s: %v", ip, err)
+		}
+		t.Fatal(err)
+	}
+	var opts []grpc.ServerOption
+	if interceptor != nil {
+		opts = append(opts, grpc.UnaryInterceptor(interceptor))
+	}
+	srv := grpc.NewServer(opts...)
+	healthpb.RegisterHealthServer(srv, health.NewServer())
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+	return lis.Addr().String()
+}
+
+// startHealthServer starts an in-process gRPC server exposing the health service.
+// Incoming metadata of each call is passed to onMD when non-nil.
+func startHealthServer(t *testing.T, onMD func(metadata.MD)) string {
+	t.Helper()
+	return startHealthServerWith(t, "127.0.0.1", func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if onMD != nil {
+			md, _ := metadata.FromIncomingContext(ctx)
+			onMD(md)
+		}
+		return handler(ctx, req)
+	})
+}
+
+// TestTracingSpans verifies that a unary call produces a call span with phase
+// events, an attempt child span, and W3C propagation to the server.
+func TestTracingSpans(t *testing.T) {
+	var gotTraceparent string
+	addr := startHealthServer(t, func(md metadata.MD) {
+		if v := md.Get("traceparent"); len(v) > 0 {
+			gotTraceparent = v[0]
+		}
+	})
+
+	rec := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(rec))
+
+	cc, err := New(context.Background(), "passthrough:///"+addr,
+		WithTCPSampling(0),
+		WithTracing(),
+		WithTracerProvider(tp),
+		WithDialOptions(grpc.WithTransportCredentials(insecure.NewCredentials())),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cc.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if _, err := healthpb.NewHealthClient(cc).Check(ctx, &healthpb.HealthCheckRequest{}); err != nil {
+		t.Fatal(err)
+	}
+
+	var call, attempt sdktrace.ReadOnlySpan
+	for _, s := range rec.Ended() {
+		switch s.Name() {
+		case "grpc.health.v1.Health/Check":
+			call = s
+		case "Attempt.grpc.health.v1.Health/Check":
+			attempt = s
+		}
+	}
+	if call == nil || attempt == nil {
+		t.Fatalf("missing spans: call=%v attempt=%v", call, attempt)
+	}
+	if attempt.Parent().SpanID() != call.SpanContext().SpanID() {
+		t.Error("attempt span is not a child of the call span")
+	}
+
+	events := make(map[string]bool)
+	for _, e := range call.Events() {
+		events[e.Name] = true
+	}
+	for _, name := range []string{"out_header", "out_payload", "in_header", "in_payload"} {
+		if !events[name] {
+			t.Errorf("call span missing %q event; got %v", name, events)
+		}
+	}
+
+	if gotTraceparent == "" {
+		t.Fatal("server did not receive traceparent")
+	}
+	if want := attempt.SpanContext().SpanID().String(); len(gotTraceparent) < 52 || gotTraceparent[36:52] != want {
+		t.Errorf("traceparent %q does not carry attempt span %s", gotTraceparent, want)
+	}
+}
@@ -1,11 +1,125 @@
 package rgrpc
 
-import "time"
+import (
+	"time"
 
+	"google.golang.org/grpc/codes"
+)
+
+// TCPInfoSummary is a TCP_INFO snapshot of one connection (Linux only;
+// Available is false elsewhere or when the socket could not be queried).
+//
+// Segment counts are in MSS-sized segments, rates in bytes per second. The
+// *Limited and BusyTime durations are cumulative over the connection's life.
 type TCPInfoSummary struct {
 	Available bool
 
-	RTT          time.Duration
-	SndCwnd      uint32
+	RTT    time.Duration
+	RTTVar time.Duration
+	MinRTT time.Duration // lowest RTT observed over the connection's life
+
+	SndCwnd     uint32
+	SndSsthresh uint32 // 0x7fffffff while still in slow start
+
 	TotalRetrans uint32
+	Unacked      uint32 // segments in flight
+	Lost         uint32 // segments presumed lost
+	Retrans      uint32 // retransmitted segments not yet acknowledged
+	Reordering   uint32 // reordering degree the sender tolerates
+
+	RcvSpace     uint32 // receive buffer autotuning estimate, in bytes
+	NotsentBytes uint32 // bytes written by the application but not yet sent
+
+	DeliveryRate uint64
+	PacingRate   uint64
+
+	// BusyTime is the time with data in flight. RwndLimited and SndbufLimited
+	// are the parts of it the sender was blocked by the peer's receive window
+	// (receiver backpressure) or by the local send buffer.
+	BusyTime      time.Duration
+	RwndLimited   time.Duration
+	SndbufLimited time.Duration
+
+	BytesSent  uint64 // including retransmissions
+	BytesAcked uint64
+}
+
+// ConnectionSummary describes a tracked connection when it closes, with a
+// final TCP_INFO snapshot taken just before its socket is closed.
+type ConnectionSummary struct {
+	LocalAddr  string
+	RemoteAddr string
+	RemoteIP   string
+
+	// Lifetime is the time from the dialer returning the connection to Close.
+	Lifetime time.Duration
+
+	TCP TCPInfoSummary
+}
+
+// Reasons for an outlier ejection (OutlierEvent.Reason, and the reason
+// attribute of outlier_ejections).
+const (
+	OutlierReasonSuccessRate = "success_rate"
+	OutlierReasonLatency     = "latency"
+)
+
+// OutlierEvent reports that outlier detection ejected a backend, or that an
+// ejected backend was returned to load balancing.
+type OutlierEvent struct {
+	RemoteIP string
+	Ejected  bool // false when the backend is returned
+
+	// Reason is OutlierReasonSuccessRate or OutlierReasonLatency; empty when
+	// the backend is returned.
+	Reason string
+
+	// Duration is how long the backend is ejected for.
+	Duration time.Duration
+
+	// The backend's statistics over the last interval, and the fleet's:
+	// mean success rate and median p99 response wait. Zero when the backend
+	// is returned.
+	SuccessRate          float64
+	FleetSuccessRate     float64
+	ResponseWaitP99      time.Duration
+	FleetResponseWaitP99 time.Duration
+}
+
+// CallRecord describes one finished call, with the same phase breakdown that
+// is recorded in the call histograms. For streams, Total and ResponseWait end
+// at the first response message (TTFB) and the byte counts cover the whole
+// stream.
+type CallRecord struct {
+	Method string // full method name, "/pkg.Service/Method"
+	Target string // target the client was created with
+
+	// RemoteAddr and LocalAddr are the TCP addresses of the connection of
+	// the first attempt; empty for non-TCP transports. RemoteIP is the
+	// remote_ip metric label.
+	RemoteAddr string
+	LocalAddr  string
+	RemoteIP   string
+
+	Start     time.Time
+	Streaming bool
+
+	Total           time.Duration
+	PickWait        time.Duration
+	StreamEstablish time.Duration
+	SendStall       time.Duration
+	ResponseWait    time.Duration
+	PickBlocked     bool // a pick waited for a READY subchannel
+	Attempts        int
+
+	Code       codes.Code
+	ErrorClass string // ok, client_cancel, client_deadline, server, transport
+	Err        error  // nil on success
+
+	// BytesSent and BytesReceived are the wire lengths of all messages,
+	// across attempts.
+	BytesSent     int64
+	BytesReceived int64
+
+	TraceID string // call span's trace ID when tracing is enabled
 }
@@ -0,0 +1,32 @@
+package rgrpc
+
+import (
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+)
+
+// ExponentialHistogramView returns an SDK view that aggregates every rgrpc
+// histogram with the given metric prefix as a base-2 exponential histogram,
+// overriding the explicit bucket boundaries. Register it on the provider:
+//
+//	mp := sdkmetric.NewMeterProvider(
+//	    sdkmetric.WithReader(exporter),
+//	    sdkmetric.WithView(rgrpc.ExponentialHistogramView("rgrpc")),
+//	)
+//
+// Exponential histograms adapt their resolution to the observed range, which
+// suits sub-millisecond and multi-second calls alike. The exporter must
+// support them (OTLP does; Prometheus needs native histograms enabled).
+func ExponentialHistogramView(prefix string) sdkmetric.View {
+	return sdkmetric.NewView(
+		sdkmetric.Instrument{
+			Name: prefix + ".*",
+			Kind: sdkmetric.InstrumentKindHistogram,
+		},
+		sdkmetric.Stream{
+			Aggregation: sdkmetric.AggregationBase2ExponentialHistogram{
+				MaxSize:  160,
+				MaxScale: 20,
+			},
+		},
+	)
+}